@@ -0,0 +1,162 @@
+package main
+
+import (
+  "bufio"
+  "flag"
+  "fmt"
+  "io/ioutil"
+  "os"
+  "sort"
+  "strconv"
+  "strings"
+
+  . "./src"
+)
+
+type breakpoints []string
+
+func (b *breakpoints) String() string { return strings.Join(*b, ",") }
+func (b *breakpoints) Set(value string) error {
+  *b = append(*b, value)
+  return nil
+}
+
+const debugHelp = `commands:
+  c, continue       run until a breakpoint
+  s, step           step to the next line, into calls
+  n, next           step to the next line of this function
+  o, out            step out of this function
+  b [file:]line     set a breakpoint
+  d [file:]line     delete a breakpoint
+  bt                print the call stack
+  l, locals         print local variables
+  p expr            evaluate expr in the current scope
+  q, quit           stop the program`
+
+// debugCommand runs a file under the debugger, it stops before the first
+// expression unless breakpoints are given with --break.
+func debugCommand(args []string) int {
+  flags := flag.NewFlagSet("debug", flag.ExitOnError)
+  var breaks breakpoints
+  flags.Var(&breaks, "break", "`[file:]line` to stop at, repeatable")
+  flags.Parse(args)
+
+  if flags.NArg() != 1 {
+    fmt.Fprintln(os.Stderr, "usage: yasp debug [--break file:line] file")
+    return 2
+  }
+
+  path := flags.Arg(0)
+  source, err := ioutil.ReadFile(path)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, err)
+    return 1
+  }
+
+  parsing, err := Parse(string(source), path)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, err)
+    return 1
+  }
+
+  lines := strings.Split(string(source), "\n")
+  in := bufio.NewScanner(os.Stdin)
+
+  debugger := NewDebugger()
+  for _, b := range breaks {
+    file, line, err := parseBreakpoint(b, path)
+    if err != nil {
+      fmt.Fprintln(os.Stderr, err)
+      return 2
+    }
+
+    debugger.SetBreakpoint(file, line)
+  }
+  if len(breaks) == 0 { debugger.StepIn() }
+
+  debugger.OnPause = func (p *Pause) {
+    fmt.Printf("%v: %v\n", p.Pos, p.Reason)
+    if p.Pos.File == path && p.Pos.Line <= len(lines) { fmt.Printf("%4d  %v\n", p.Pos.Line, lines[p.Pos.Line - 1]) }
+
+    for {
+      fmt.Print("(debug) ")
+      if !in.Scan() {
+        debugger.Continue()
+        return
+      }
+
+      text := strings.TrimSpace(in.Text())
+      command := strings.Fields(text)
+      if len(command) == 0 { continue }
+
+      switch command[0] {
+      case "c", "continue": debugger.Continue(); return
+      case "s", "step": debugger.StepIn(); return
+      case "n", "next": debugger.StepOver(); return
+      case "o", "out": debugger.StepOut(); return
+      case "q", "quit": os.Exit(0)
+      case "b", "d": {
+        if len(command) != 2 {
+          fmt.Println("expected [file:]line")
+          continue
+        }
+
+        file, line, err := parseBreakpoint(command[1], p.Pos.File)
+        if err != nil {
+          fmt.Println(err)
+        } else if command[0] == "b" {
+          debugger.SetBreakpoint(file, line)
+        } else {
+          debugger.ClearBreakpoint(file, line)
+        }
+      }
+      case "bt": {
+        for _, frame := range p.Frames {
+          fmt.Printf("  %v\n", frame.Describe())
+        }
+        fmt.Println("  <top level>")
+      }
+      case "l", "locals": {
+        for _, scope := range p.Locals() {
+          names := []string{}
+          for name := range scope { names = append(names, name) }
+          sort.Strings(names)
+
+          for _, name := range names {
+            fmt.Printf("  %v = %v\n", name, scope[name].Print())
+          }
+        }
+      }
+      case "p": {
+        if len(command) == 1 {
+          fmt.Println("expected an expression")
+          continue
+        }
+
+        fmt.Println(p.Eval(text[1:]).Print())
+      }
+      default: fmt.Println(debugHelp)
+      }
+    }
+  }
+
+  context := EmptyEvaluationContext()
+  context.Strict = true
+  context.Debugger = debugger
+
+  if _, err := parsing.Run(context); err != nil {
+    fmt.Fprintln(os.Stderr, err)
+    return 1
+  }
+
+  return 0
+}
+
+func parseBreakpoint(s string, file string) (string, int, error) {
+  if i := strings.LastIndex(s, ":"); i >= 0 { file, s = s[:i], s[i + 1:] }
+
+  line, err := strconv.Atoi(s)
+  if err != nil || line < 1 { return "", 0, fmt.Errorf("invalid breakpoint line: %v", s) }
+
+  return file, line, nil
+}
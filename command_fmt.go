@@ -0,0 +1,76 @@
+package main
+
+import (
+  "flag"
+  "fmt"
+  "io/ioutil"
+  "os"
+
+  . "./src"
+)
+
+// fmtCommand formats files in place, or stdin to stdout. With --check
+// it only prints diffs and fails when a file isn't formatted.
+func fmtCommand(args []string) int {
+  flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+  check := flags.Bool("check", false, "print diffs instead of rewriting files")
+  width := flags.Int("width", 80, "line width")
+  flags.Parse(args)
+
+  if flags.NArg() == 0 {
+    source, err := ioutil.ReadAll(os.Stdin)
+    if err != nil {
+      fmt.Fprintln(os.Stderr, err)
+      return 1
+    }
+
+    return formatFile("<stdin>", string(source), *width, *check, func (formatted string) error {
+      _, err := fmt.Print(formatted)
+      return err
+    })
+  }
+
+  status := 0
+
+  for _, path := range flags.Args() {
+    source, err := ioutil.ReadFile(path)
+    if err != nil {
+      fmt.Fprintln(os.Stderr, err)
+      status = 1
+      continue
+    }
+
+    if formatFile(path, string(source), *width, *check, func (formatted string) error {
+      if formatted == string(source) { return nil }
+
+      return ioutil.WriteFile(path, []byte(formatted), 0644)
+    }) != 0 {
+      status = 1
+    }
+  }
+
+  return status
+}
+
+func formatFile(path string, source string, width int, check bool, write func (string) error) int {
+  formatted, err := Format(source, path, width)
+  if err != nil {
+    fmt.Fprintln(os.Stderr, err)
+    return 1
+  }
+
+  if check {
+    diff := Diff(path, source, formatted)
+    if diff == "" { return 0 }
+
+    fmt.Print(diff)
+    return 1
+  }
+
+  if err := write(formatted); err != nil {
+    fmt.Fprintln(os.Stderr, err)
+    return 1
+  }
+
+  return 0
+}
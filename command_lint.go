@@ -0,0 +1,61 @@
+package main
+
+import (
+  "encoding/json"
+  "flag"
+  "fmt"
+  "io/ioutil"
+  "os"
+  "strings"
+
+  . "./src"
+)
+
+// lintCommand checks files, or stdin, and fails when anything is found.
+// With --json diagnostics are printed as a JSON array.
+func lintCommand(args []string) int {
+  flags := flag.NewFlagSet("lint", flag.ExitOnError)
+  asJSON := flags.Bool("json", false, "print diagnostics as JSON")
+  disable := flags.String("disable", "", "comma separated rules to skip: " + strings.Join(LintRules, ", "))
+  flags.Parse(args)
+
+  config := LintConfig{Disabled: make(map[string]bool)}
+  for _, rule := range strings.Split(*disable, ",") {
+    if rule != "" { config.Disabled[strings.TrimSpace(rule)] = true }
+  }
+
+  diagnostics := []Diagnostic{}
+  status := 0
+
+  lint := func (path string, source []byte, err error) {
+    if err != nil {
+      fmt.Fprintln(os.Stderr, err)
+      status = 1
+      return
+    }
+
+    diagnostics = append(diagnostics, Lint(string(source), path, config)...)
+  }
+
+  if flags.NArg() == 0 {
+    source, err := ioutil.ReadAll(os.Stdin)
+    lint("<stdin>", source, err)
+  }
+  for _, path := range flags.Args() {
+    source, err := ioutil.ReadFile(path)
+    lint(path, source, err)
+  }
+
+  if *asJSON {
+    out, _ := json.MarshalIndent(diagnostics, "", "  ")
+    fmt.Println(string(out))
+  } else {
+    for _, d := range diagnostics {
+      fmt.Println(d)
+    }
+  }
+
+  if len(diagnostics) > 0 { status = 1 }
+
+  return status
+}
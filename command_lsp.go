@@ -0,0 +1,18 @@
+package main
+
+import (
+  "fmt"
+  "os"
+
+  . "./lsp"
+)
+
+// lspCommand serves the Language Server Protocol over stdin and stdout.
+func lspCommand() int {
+  if err := NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+    fmt.Fprintln(os.Stderr, err)
+    return 1
+  }
+
+  return 0
+}
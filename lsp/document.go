@@ -0,0 +1,132 @@
+package yaspLsp
+
+import (
+  "net/url"
+  "sort"
+  "unicode/utf16"
+
+  yasp "../src"
+)
+
+// document is an open text document, positions convert between rune
+// offsets used by the parser and LSP lines and UTF-16 characters.
+type document struct {
+  uri string
+  path string
+  text string
+  runes []rune
+  lineStarts []int
+
+  // nil when the text has syntax errors
+  parsing *yasp.Parsing
+}
+
+func newDocument(uri string, text string) *document {
+  d := &document{uri: uri, path: uri, text: text, runes: []rune(text), lineStarts: []int{0}}
+
+  if u, err := url.Parse(uri); err == nil && u.Scheme == "file" { d.path = u.Path }
+
+  for i, r := range d.runes {
+    if r == '\n' { d.lineStarts = append(d.lineStarts, i + 1) }
+  }
+
+  if parsing, err := yasp.Parse(text, d.path); err == nil { d.parsing = parsing }
+
+  return d
+}
+
+func (d *document) position(offset int) Position {
+  if offset > len(d.runes) { offset = len(d.runes) }
+
+  line := sort.Search(len(d.lineStarts), func (i int) bool { return d.lineStarts[i] > offset }) - 1
+
+  return Position{Line: line, Character: len(utf16.Encode(d.runes[d.lineStarts[line]:offset]))}
+}
+
+func (d *document) offset(p Position) int {
+  if p.Line < 0 { return 0 }
+  if p.Line >= len(d.lineStarts) { return len(d.runes) }
+
+  offset, units := d.lineStarts[p.Line], 0
+  for offset < len(d.runes) && d.runes[offset] != '\n' && units < p.Character {
+    units += len(utf16.Encode([]rune{d.runes[offset]}))
+    offset++
+  }
+
+  return offset
+}
+
+// span returns the range of the expression starting at offset, or of the
+// single character when it isn't known.
+func (d *document) span(offset int) Range {
+  end := offset + 1
+  if d.parsing != nil {
+    if e, ok := d.parsing.Spans[offset]; ok { end = e }
+  }
+
+  return Range{Start: d.position(offset), End: d.position(end)}
+}
+
+func (d *document) walk(visit func (v *yasp.Value)) {
+  if d.parsing == nil { return }
+
+  var walk func (v *yasp.Value)
+  walk = func (v *yasp.Value) {
+    visit(v)
+
+    if v.T == yasp.TypeExpression {
+      for _, x := range v.AssertExpressionType().Expand() {
+        walk(x)
+      }
+    }
+  }
+
+  for _, form := range d.parsing.Forms() {
+    walk(form)
+  }
+}
+
+// identifierAt returns the identifier under the offset, the cursor may be
+// right after it.
+func (d *document) identifierAt(offset int) *yasp.Value {
+  var found *yasp.Value
+
+  d.walk(func (v *yasp.Value) {
+    if v.T == yasp.TypeID && v.Pos.Offset <= offset && offset <= d.parsing.Spans[v.Pos.Offset] { found = v }
+  })
+
+  return found
+}
+
+// definition is a def, defn, defstruct or defenum form of the document.
+type definition struct {
+  form string
+  name *yasp.Value
+  elems []*yasp.Value
+}
+
+func (d *document) definitions() map[string]definition {
+  definitions := make(map[string]definition)
+
+  d.walk(func (v *yasp.Value) {
+    if v.T != yasp.TypeExpression { return }
+
+    elems := v.AssertExpressionType().Expand()
+    if len(elems) < 2 || elems[0].T != yasp.TypeID || elems[1].T != yasp.TypeID { return }
+
+    switch form := elems[0].AssertIdType(); form {
+    case "def", "defn", "defstruct", "defenum": {
+      // the first definition wins
+      if _, ok := definitions[elems[1].AssertIdType()]; !ok {
+        definitions[elems[1].AssertIdType()] = definition{form: form, name: elems[1], elems: elems}
+      }
+    }
+    }
+  })
+
+  return definitions
+}
+
+func (d *document) verbatim(v *yasp.Value) string {
+  return string(d.runes[v.Pos.Offset:d.parsing.Spans[v.Pos.Offset]])
+}
@@ -0,0 +1,162 @@
+package yaspLsp
+
+import (
+  "bufio"
+  "encoding/json"
+  "fmt"
+  "io"
+  "net/textproto"
+  "strconv"
+)
+
+// Messages are JSON-RPC 2.0 objects framed with a Content-Length header,
+// requests have an ID and notifications don't.
+type message struct {
+  JSONRPC string `json:"jsonrpc"`
+  ID *json.RawMessage `json:"id,omitempty"`
+  Method string `json:"method"`
+  Params json.RawMessage `json:"params,omitempty"`
+}
+
+// response has either a result, which may be null, or an error.
+type response struct {
+  JSONRPC string `json:"jsonrpc"`
+  ID *json.RawMessage `json:"id"`
+  Result interface {} `json:"result"`
+}
+
+type errorResponse struct {
+  JSONRPC string `json:"jsonrpc"`
+  ID *json.RawMessage `json:"id"`
+  Error responseError `json:"error"`
+}
+
+type responseError struct {
+  Code int `json:"code"`
+  Message string `json:"message"`
+}
+
+const (
+  codeMethodNotFound = -32601
+  codeInvalidParams = -32602
+  codeInternalError = -32603
+)
+
+func readMessage(in *bufio.Reader) (*message, error) {
+  header, err := textproto.NewReader(in).ReadMIMEHeader()
+  if err != nil { return nil, err }
+
+  length, err := strconv.Atoi(header.Get("Content-Length"))
+  if err != nil { return nil, fmt.Errorf("invalid Content-Length: %v", header.Get("Content-Length")) }
+
+  body := make([]byte, length)
+  if _, err := io.ReadFull(in, body); err != nil { return nil, err }
+
+  m := &message{}
+  if err := json.Unmarshal(body, m); err != nil { return nil, err }
+
+  return m, nil
+}
+
+func writeMessage(out io.Writer, m interface {}) error {
+  body, err := json.Marshal(m)
+  if err != nil { return err }
+
+  _, err = fmt.Fprintf(out, "Content-Length: %v\r\n\r\n%s", len(body), body)
+
+  return err
+}
+
+// Position is zero based, Character counts UTF-16 code units.
+type Position struct {
+  Line int `json:"line"`
+  Character int `json:"character"`
+}
+
+type Range struct {
+  Start Position `json:"start"`
+  End Position `json:"end"`
+}
+
+type Location struct {
+  URI string `json:"uri"`
+  Range Range `json:"range"`
+}
+
+type Diagnostic struct {
+  Range Range `json:"range"`
+  Severity int `json:"severity"`
+  Code string `json:"code"`
+  Source string `json:"source"`
+  Message string `json:"message"`
+}
+
+const (
+  severityError = 1
+  severityWarning = 2
+)
+
+type TextEdit struct {
+  Range Range `json:"range"`
+  NewText string `json:"newText"`
+}
+
+type MarkupContent struct {
+  Kind string `json:"kind"`
+  Value string `json:"value"`
+}
+
+type Hover struct {
+  Contents MarkupContent `json:"contents"`
+  Range *Range `json:"range,omitempty"`
+}
+
+type CompletionItem struct {
+  Label string `json:"label"`
+  Kind int `json:"kind"`
+  Detail string `json:"detail,omitempty"`
+}
+
+const (
+  completionFunction = 3
+  completionVariable = 6
+  completionStruct = 22
+  completionKeyword = 14
+)
+
+type textDocumentIdentifier struct {
+  URI string `json:"uri"`
+}
+
+type textDocumentPositionParams struct {
+  TextDocument textDocumentIdentifier `json:"textDocument"`
+  Position Position `json:"position"`
+}
+
+type didOpenParams struct {
+  TextDocument struct {
+    URI string `json:"uri"`
+    Text string `json:"text"`
+  } `json:"textDocument"`
+}
+
+// didChangeParams with full document sync, every change is the whole text.
+type didChangeParams struct {
+  TextDocument textDocumentIdentifier `json:"textDocument"`
+  ContentChanges []struct {
+    Text string `json:"text"`
+  } `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+  TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type formattingParams struct {
+  TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type publishDiagnosticsParams struct {
+  URI string `json:"uri"`
+  Diagnostics []Diagnostic `json:"diagnostics"`
+}
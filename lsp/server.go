@@ -0,0 +1,271 @@
+package yaspLsp
+
+import (
+  "bufio"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "io"
+  "sort"
+  "strings"
+  "unicode"
+
+  yasp "../src"
+)
+
+// Server speaks the Language Server Protocol, documents are synced in
+// full and diagnostics come from the parser and the linter.
+type Server struct {
+  in *bufio.Reader
+  out io.Writer
+
+  documents map[string]*document
+  // prelude definitions
+  globals map[string]*yasp.Value
+  shutdown bool
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+  s := &Server{in: bufio.NewReader(in), out: out, documents: make(map[string]*document), globals: make(map[string]*yasp.Value)}
+
+  for context := yasp.EmptyEvaluationContext(); context != nil; context = context.Parent {
+    for name, value := range context.Vars {
+      s.globals[name] = value
+    }
+  }
+
+  return s
+}
+
+// Run serves requests until the exit notification, it fails when the
+// client exits without shutdown or the connection breaks.
+func (s *Server) Run() error {
+  for {
+    m, err := readMessage(s.in)
+    if err == io.EOF { return errors.New("connection closed before exit") }
+    if err != nil { return err }
+
+    if m.Method == "exit" {
+      if !s.shutdown { return errors.New("exit before shutdown") }
+
+      return nil
+    }
+
+    result, rerr := s.handle(m)
+
+    // notifications have no response
+    if m.ID == nil { continue }
+
+    if rerr != nil {
+      err = writeMessage(s.out, &errorResponse{JSONRPC: "2.0", ID: m.ID, Error: *rerr})
+    } else {
+      err = writeMessage(s.out, &response{JSONRPC: "2.0", ID: m.ID, Result: result})
+    }
+    if err != nil { return err }
+  }
+}
+
+func (s *Server) notify(method string, params interface {}) error {
+  body, err := json.Marshal(params)
+  if err != nil { return err }
+
+  return writeMessage(s.out, &message{JSONRPC: "2.0", Method: method, Params: body})
+}
+
+func (s *Server) handle(m *message) (result interface {}, rerr *responseError) {
+  // a failing handler shouldn't take the editor session down
+  defer func() {
+    if r := recover(); r != nil {
+      result, rerr = nil, &responseError{Code: codeInternalError, Message: fmt.Sprint(r)}
+    }
+  }()
+
+  decode := func (params interface {}) *responseError {
+    if err := json.Unmarshal(m.Params, params); err != nil {
+      return &responseError{Code: codeInvalidParams, Message: err.Error()}
+    }
+
+    return nil
+  }
+
+  switch m.Method {
+  case "initialize": {
+    return map[string]interface {}{
+      "capabilities": map[string]interface {}{
+        "textDocumentSync": 1,
+        "definitionProvider": true,
+        "hoverProvider": true,
+        "completionProvider": map[string]interface {}{},
+        "documentFormattingProvider": true,
+      },
+      "serverInfo": map[string]string{"name": "yasp"},
+    }, nil
+  }
+  case "initialized": return nil, nil
+  case "shutdown": {
+    s.shutdown = true
+    return nil, nil
+  }
+  case "textDocument/didOpen": {
+    params := didOpenParams{}
+    if err := decode(&params); err != nil { return nil, err }
+
+    s.open(params.TextDocument.URI, params.TextDocument.Text)
+  }
+  case "textDocument/didChange": {
+    params := didChangeParams{}
+    if err := decode(&params); err != nil { return nil, err }
+
+    if n := len(params.ContentChanges); n > 0 { s.open(params.TextDocument.URI, params.ContentChanges[n - 1].Text) }
+  }
+  case "textDocument/didClose": {
+    params := didCloseParams{}
+    if err := decode(&params); err != nil { return nil, err }
+
+    delete(s.documents, params.TextDocument.URI)
+    s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+  }
+  case "textDocument/definition", "textDocument/hover", "textDocument/completion": {
+    params := textDocumentPositionParams{}
+    if err := decode(&params); err != nil { return nil, err }
+
+    d, ok := s.documents[params.TextDocument.URI]
+    if !ok { return nil, nil }
+
+    switch m.Method {
+    case "textDocument/definition": return s.definition(d, d.offset(params.Position)), nil
+    case "textDocument/hover": return s.hover(d, d.offset(params.Position)), nil
+    default: return s.completion(d, d.offset(params.Position)), nil
+    }
+  }
+  case "textDocument/formatting": {
+    params := formattingParams{}
+    if err := decode(&params); err != nil { return nil, err }
+
+    d, ok := s.documents[params.TextDocument.URI]
+    if !ok { return nil, nil }
+
+    return s.formatting(d), nil
+  }
+  default: {
+    if m.ID != nil { return nil, &responseError{Code: codeMethodNotFound, Message: "unsupported method " + m.Method} }
+  }
+  }
+
+  return nil, nil
+}
+
+func (s *Server) open(uri string, text string) {
+  d := newDocument(uri, text)
+  s.documents[uri] = d
+
+  diagnostics := []Diagnostic{}
+
+  for _, x := range yasp.Lint(text, d.path, yasp.LintConfig{}) {
+    severity := severityWarning
+    if x.Severity == "error" { severity = severityError }
+
+    diagnostics = append(diagnostics, Diagnostic{Range: d.span(x.Pos.Offset), Severity: severity, Code: x.Rule, Source: "yasp", Message: x.Message})
+  }
+
+  s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
+
+func (s *Server) definition(d *document, offset int) interface {} {
+  id := d.identifierAt(offset)
+  if id == nil { return nil }
+
+  def, ok := d.definitions()[id.AssertIdType()]
+  if !ok { return nil }
+
+  return Location{URI: d.uri, Range: d.span(def.name.Pos.Offset)}
+}
+
+func (s *Server) hover(d *document, offset int) interface {} {
+  id := d.identifierAt(offset)
+  if id == nil { return nil }
+
+  name := id.AssertIdType()
+  text := ""
+
+  if def, ok := d.definitions()[name]; ok {
+    switch {
+    case def.form == "defn" && len(def.elems) > 2: text = "(defn " + name + " " + d.verbatim(def.elems[2]) + ")"
+    case def.form == "def": text = "(def " + name + ")"
+    default: text = "(" + strings.Join(verbatims(d, def.elems), " ") + ")"
+    }
+  } else if value, ok := s.globals[name]; ok && value.T == yasp.TypeFunction {
+    text = "(defn " + name + " " + value.AssertFunctionType().Parameters() + ")"
+  } else if value, ok := s.globals[name]; ok {
+    text = "(def " + name + " " + value.Print() + ")"
+  } else if builtin, ok := yasp.Builtins[name]; ok {
+    text = "builtin " + name + ", " + builtin.String()
+  } else {
+    return nil
+  }
+
+  r := d.span(id.Pos.Offset)
+  return Hover{Contents: MarkupContent{Kind: "markdown", Value: "```yasp\n" + text + "\n```"}, Range: &r}
+}
+
+func verbatims(d *document, values []*yasp.Value) []string {
+  texts := make([]string, len(values))
+
+  for i, v := range values {
+    texts[i] = d.verbatim(v)
+  }
+
+  return texts
+}
+
+func (s *Server) completion(d *document, offset int) []CompletionItem {
+  start := offset
+  for start > 0 && !unicode.IsSpace(d.runes[start - 1]) && !strings.ContainsRune("()'\"", d.runes[start - 1]) {
+    start--
+  }
+  prefix := string(d.runes[start:offset])
+
+  items := make(map[string]CompletionItem)
+  add := func (item CompletionItem) {
+    if _, ok := items[item.Label]; !ok && strings.HasPrefix(item.Label, prefix) { items[item.Label] = item }
+  }
+
+  for name, def := range d.definitions() {
+    kind := completionVariable
+    switch def.form {
+    case "defn": kind = completionFunction
+    case "defstruct", "defenum": kind = completionStruct
+    }
+
+    add(CompletionItem{Label: name, Kind: kind})
+  }
+
+  for name, value := range s.globals {
+    if value.T == yasp.TypeFunction {
+      add(CompletionItem{Label: name, Kind: completionFunction, Detail: value.AssertFunctionType().Parameters()})
+    } else {
+      add(CompletionItem{Label: name, Kind: completionVariable})
+    }
+  }
+
+  for name, builtin := range yasp.Builtins {
+    if name == yasp.Interpolation { continue }
+
+    add(CompletionItem{Label: name, Kind: completionKeyword, Detail: builtin.String()})
+  }
+
+  sorted := []CompletionItem{}
+  for _, item := range items {
+    sorted = append(sorted, item)
+  }
+  sort.Slice(sorted, func (i, j int) bool { return sorted[i].Label < sorted[j].Label })
+
+  return sorted
+}
+
+func (s *Server) formatting(d *document) []TextEdit {
+  formatted, err := yasp.Format(d.text, d.path, 80)
+  if err != nil || formatted == d.text { return []TextEdit{} }
+
+  return []TextEdit{{Range: Range{Start: Position{}, End: d.position(len(d.runes))}, NewText: formatted}}
+}
@@ -1,39 +1,61 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"log"
   "bufio"
+  "io"
   "os"
-  "bytes"
 
   . "./src"
   //. "./util"
 )
 
 func main() {
-  var buffer bytes.Buffer
-
-  scanner := bufio.NewScanner(os.Stdin)
-  scanner.Split(bufio.ScanBytes)
-
-  for scanner.Scan() {
-    buffer.WriteString(scanner.Text())
-  }
-
-  source := buffer.String()
-
-  yaspPeg := &YaspPEG{Buffer: source}
-  yaspPeg.Init()
-  yaspPeg.Parsing.Init()
-  if err := yaspPeg.Parse(); err != nil {
-    log.Fatal(err)
+  bare := flag.Bool("bare", false, "start without the prelude")
+  strict := flag.Bool("strict", true, "fail on unbound identifiers instead of reading them as symbols")
+  flag.Parse()
+
+  switch flag.Arg(0) {
+  case "fmt": os.Exit(fmtCommand(flag.Args()[1:]))
+  case "lint": os.Exit(lintCommand(flag.Args()[1:]))
+  case "lsp": os.Exit(lspCommand())
+  case "debug": os.Exit(debugCommand(flag.Args()[1:]))
   }
-  yaspPeg.Execute()
 
   context := EmptyEvaluationContext()
+  if *bare { context = BareEvaluationContext() }
+  context.Strict = *strict
+
+  // forms are evaluated as soon as they are read
+  reader := NewIncrementalReader("<stdin>")
+  in := bufio.NewReader(os.Stdin)
+  failed := false
+
+  for {
+    line, err := in.ReadString('\n')
+    reader.Feed(line)
+    if err != nil { reader.Close() }
+
+    for {
+      form, err := reader.Next()
+      if err == ErrIncomplete || err == io.EOF { break }
+      if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        failed = true
+        continue
+      }
+
+      if _, err := form.Run(context); err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        failed = true
+      }
+    }
+
+    if err != nil { break }
+  }
 
-  yaspPeg.Evaluate(context)
+  if failed { os.Exit(1) }
 
   fmt.Printf("%v\n", context.Vars["main"].EvaluateFunction(context, []*Value{&Value{T: TypeString, V: "  (+ 1 2)"}}))
 
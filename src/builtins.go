@@ -0,0 +1,60 @@
+package yasp
+
+import ( "fmt" )
+
+// Builtin is the number of arguments a builtin accepts, Max is -1 when
+// there is no limit.
+type Builtin struct {
+  Min int
+  Max int
+}
+
+// Builtins lists functions and special forms evaluated by Stack.Evaluate,
+// tools like the linter check calls against it.
+var Builtins = map[string]Builtin{
+  "+": {0, -1}, "*": {0, -1}, "-": {2, 2},
+  "=": {2, 2}, "<": {2, 2}, ">=": {2, 2}, "<=": {2, 2},
+  "or": {0, -1}, "and": {0, -1}, "not": {1, 1},
+  "ord": {1, 1}, "listToString": {1, 1},
+
+  "let": {2, 2}, "fn": {2, 2}, "defn": {3, 3}, "def": {2, 2}, "set!": {2, 2},
+  "if": {3, 3}, "do": {0, -1}, "switch": {1, -1}, "match": {1, -1}, "quote": {1, 1},
+
+  "head": {1, 1}, "tail": {1, 1}, "last": {1, 1}, "untail": {1, 1},
+  "take": {2, 2}, "skip": {2, 2}, "len": {1, 1}, "empty?": {1, 1},
+  "in": {2, 2}, "get": {2, 2}, "getOrDef": {3, 3},
+  "append": {2, 2}, "prepend": {2, 2}, "list": {0, -1}, "typeof": {1, 1},
+
+  "try": {0, -1}, "throw": {1, 1}, "error": {1, 2}, "error?": {1, 1},
+  "error-message": {1, 1}, "error-data": {1, 1}, "error-trace": {1, 1},
+
+  "module": {1, -1}, "import": {1, 3}, "export": {0, -1},
+
+  "atom": {1, 1}, "deref": {1, 1}, "reset!": {2, 2}, "swap!": {2, -1},
+  "defstruct": {1, -1}, "defenum": {1, -1},
+
+  "map": {2, -1}, "filter": {2, 2}, "reduce": {2, 3}, "range": {1, 3},
+  "reverse": {1, 1}, "zip": {0, -1}, "sort": {1, 2}, "any?": {2, 2}, "all?": {2, 2},
+
+  "concat": {0, -1}, "str": {0, -1}, Interpolation: {0, -1}, "repr": {1, 1}, "read": {1, 1}, "format": {1, -1},
+  "split": {2, 2}, "join": {2, 2}, "replace": {3, 3}, "index-of": {2, 2},
+  "starts-with?": {2, 2}, "ends-with?": {2, 2}, "trim": {1, 1}, "upper": {1, 1},
+  "lower": {1, 1}, "substring": {3, 3}, "chr": {1, 1}, "repeat": {2, 2},
+  "regex": {1, 1}, "re-match": {2, 2}, "re-find": {2, 2}, "re-find-all": {2, 2},
+  "re-replace": {3, 3}, "re-split": {2, 2},
+
+  "print": {1, -1},
+}
+
+// Accepts reports whether the builtin can be called with n arguments.
+func (b Builtin) Accepts(n int) bool {
+  return n >= b.Min && (b.Max < 0 || n <= b.Max)
+}
+
+func (b Builtin) String() string {
+  switch {
+  case b.Max < 0: return fmt.Sprintf("at least %v arguments", b.Min)
+  case b.Min == b.Max: return fmt.Sprintf("%v arguments", b.Min)
+  default: return fmt.Sprintf("%v to %v arguments", b.Min, b.Max)
+  }
+}
@@ -0,0 +1,75 @@
+package yasp
+
+import (
+  "fmt"
+  "strings"
+)
+
+// Frame is a call of a user function, Pos is the call site, unknown for
+// functions called by builtins like map.
+type Frame struct {
+  Name string
+  Pos Position
+  Args []*Value
+}
+
+func (f Frame) String() string {
+  return f.Name
+}
+
+// Describe returns the call with its arguments and the call site.
+func (f Frame) Describe() string {
+  call := []string{f.Name}
+  for _, x := range f.Args {
+    call = append(call, Quote(x).Print())
+  }
+
+  text := "(" + strings.Join(call, " ") + ")"
+  if n := []rune(text); len(n) > 60 { text = string(n[:56]) + " ...)" }
+
+  if !f.Pos.IsValid() { return text }
+
+  return fmt.Sprintf("%v at %v", text, f.Pos)
+}
+
+// CallStack holds frames of user functions being evaluated.
+// Frames are popped only on successful return, so after a panic
+// they describe where it happened until the error is caught.
+type CallStack struct {
+  Frames []Frame
+}
+
+func (c *CallStack) push(frame Frame) {
+  c.Frames = append(c.Frames, frame)
+}
+func (c *CallStack) pop() {
+  c.Frames = c.Frames[:len(c.Frames) - 1]
+}
+
+func (c *CallStack) Depth() int {
+  return len(c.Frames)
+}
+
+// unwind drops frames above depth and returns them innermost first.
+func (c *CallStack) unwind(depth int) []Frame {
+  var trace []Frame
+
+  for i := len(c.Frames) - 1; i >= depth; i-- {
+    trace = append(trace, c.Frames[i])
+  }
+
+  c.Frames = c.Frames[:depth]
+
+  return trace
+}
+
+func (fv ValueFunction) Name() string {
+  if fv.name == "" { return "<anonymous>" }
+
+  return fv.name
+}
+
+func (fv ValueFunction) withName(name string) ValueFunction {
+  fv.name = name
+  return fv
+}
@@ -0,0 +1,186 @@
+package yasp
+
+import (
+  "bytes"
+  "fmt"
+  "sort"
+)
+
+// Elements returns items of a collection, strings are split into characters.
+func Elements(v *Value) []*Value {
+  switch v.T {
+  case TypeList: return v.AssertListType()
+  case TypeNil: return nil
+  case TypeString: {
+    text := v.AssertTextType()
+    elements := make([]*Value, text.Len())
+
+    for i := range elements {
+      elements[i] = textValue(text.Slice(i, i + 1))
+    }
+
+    return elements
+  }
+  default: panic(fmt.Sprintf("%v is not a collection", v))
+  }
+}
+
+// collectionLike builds a collection of the same kind as the original one,
+// so filtering a string gives a string.
+func collectionLike(original *Value, elements []*Value) *Value {
+  if original.T == TypeString {
+    var buffer bytes.Buffer
+
+    for _, x := range elements {
+      buffer.WriteString(x.AssertStringType())
+    }
+
+    return &Value{T: TypeString, V: buffer.String()}
+  }
+
+  return &Value{T: TypeList, V: elements}
+}
+
+func boolValue(b bool) *Value {
+  if b { return &Value{T: TypeNumber, V: uint64(1)} }
+
+  return &Value{T: TypeNumber, V: uint64(0)}
+}
+
+func mapCollection(context *EvaluationContext, f *Value, collections []*Value) *Value {
+  result := []*Value{}
+
+  for _, args := range zipCollections(collections).AssertListType() {
+    result = append(result, Apply(context, f, args.AssertListType()))
+  }
+
+  return &Value{T: TypeList, V: result}
+}
+
+func filterCollection(context *EvaluationContext, predicate *Value, collection *Value) *Value {
+  result := []*Value{}
+
+  for _, x := range Elements(collection) {
+    if Apply(context, predicate, []*Value{x}).Bool() { result = append(result, x) }
+  }
+
+  return collectionLike(collection, result)
+}
+
+func reduceCollection(context *EvaluationContext, f *Value, acc *Value, elements []*Value) *Value {
+  for _, x := range elements {
+    acc = Apply(context, f, []*Value{acc, x})
+  }
+
+  return acc
+}
+
+// anyInCollection checks if predicate gives expected result for some element,
+// all? is checked as absence of an element failing the predicate.
+func anyInCollection(context *EvaluationContext, predicate *Value, collection *Value, expected bool) bool {
+  for _, x := range Elements(collection) {
+    if Apply(context, predicate, []*Value{x}).Bool() == expected { return true }
+  }
+
+  return false
+}
+
+func reverseCollection(collection *Value) *Value {
+  elements := Elements(collection)
+  result := make([]*Value, len(elements))
+
+  for i, x := range elements {
+    result[len(elements) - 1 - i] = x
+  }
+
+  return collectionLike(collection, result)
+}
+
+func zipCollections(collections []*Value) *Value {
+  var lists [][]*Value
+  size := -1
+
+  for _, x := range collections {
+    elements := Elements(x)
+    if size < 0 || len(elements) < size { size = len(elements) }
+
+    lists = append(lists, elements)
+  }
+
+  result := []*Value{}
+
+  for i := 0; i < size; i++ {
+    tuple := make([]*Value, len(lists))
+    for j, elements := range lists {
+      tuple[j] = elements[i]
+    }
+
+    result = append(result, &Value{T: TypeList, V: tuple})
+  }
+
+  return &Value{T: TypeList, V: result}
+}
+
+func rangeList(start uint64, end uint64, step uint64) *Value {
+  if step == 0 { panic("range step must not be 0") }
+
+  result := []*Value{}
+
+  for i := start; i < end; i += step {
+    result = append(result, &Value{T: TypeNumber, V: i})
+
+    // i + step would wrap around near the largest number
+    if end - i <= step { break }
+  }
+
+  return &Value{T: TypeList, V: result}
+}
+
+// sortCollection sorts by optional less predicate, otherwise numbers and
+// strings are compared naturally and lists lexicographically.
+func sortCollection(context *EvaluationContext, less *Value, collection *Value) *Value {
+  elements := append([]*Value{}, Elements(collection)...)
+
+  sort.SliceStable(elements, func (i, j int) bool {
+    if less != nil { return Apply(context, less, []*Value{elements[i], elements[j]}).Bool() }
+
+    return compareValues(elements[i], elements[j]) < 0
+  })
+
+  return collectionLike(collection, elements)
+}
+
+func compareValues(a *Value, b *Value) int {
+  if a.T != b.T { panic(fmt.Sprintf("cannot compare %v and %v", a, b)) }
+
+  switch a.T {
+  case TypeNumber: {
+    an, bn := a.AssertNumberType(), b.AssertNumberType()
+
+    switch {
+    case an < bn: return -1
+    case an > bn: return 1
+    default: return 0
+    }
+  }
+  case TypeString: {
+    as, bs := a.AssertStringType(), b.AssertStringType()
+
+    switch {
+    case as < bs: return -1
+    case as > bs: return 1
+    default: return 0
+    }
+  }
+  case TypeList: {
+    al, bl := a.AssertListType(), b.AssertListType()
+
+    for i := 0; i < len(al) && i < len(bl); i++ {
+      if c := compareValues(al[i], bl[i]); c != 0 { return c }
+    }
+
+    return compareValues(&Value{T: TypeNumber, V: uint64(len(al))}, &Value{T: TypeNumber, V: uint64(len(bl))})
+  }
+  default: panic(fmt.Sprintf("Unsupported type for ordering: %v", a.T))
+  }
+}
@@ -0,0 +1,161 @@
+package yasp
+
+import (
+  "fmt"
+  "path/filepath"
+  "strings"
+)
+
+// Debugger pauses evaluation at breakpoints and steps, OnPause is called
+// with the evaluation stopped and chooses how to resume by calling
+// Continue, StepIn, StepOver or StepOut, continuing by default.
+type Debugger struct {
+  OnPause func (p *Pause)
+
+  // lines with breakpoints by file
+  breakpoints map[string]map[int]bool
+  mode stepMode
+  // call depth and line where stepping started
+  depth int
+  from Position
+  // last evaluated line by call depth, breakpoints fire when it changes
+  lines []Position
+  paused bool
+}
+
+type stepMode int
+const (
+  stepContinue stepMode = iota
+  stepIn
+  stepOver
+  stepOut
+)
+
+// Pause describes where the evaluation stopped, Context is the scope of
+// the expression about to be evaluated.
+type Pause struct {
+  Pos Position
+  Expression *Value
+  Context *EvaluationContext
+  // innermost first
+  Frames []Frame
+  Reason string
+}
+
+func NewDebugger() *Debugger {
+  return &Debugger{breakpoints: make(map[string]map[int]bool)}
+}
+
+func (d *Debugger) SetBreakpoint(file string, line int) {
+  if d.breakpoints[file] == nil { d.breakpoints[file] = make(map[int]bool) }
+
+  d.breakpoints[file][line] = true
+}
+func (d *Debugger) ClearBreakpoint(file string, line int) {
+  delete(d.breakpoints[file], line)
+}
+
+// Continue runs until a breakpoint.
+func (d *Debugger) Continue() {
+  d.mode = stepContinue
+}
+// StepIn stops at the next line, in a called function too.
+func (d *Debugger) StepIn() {
+  d.mode = stepIn
+}
+// StepOver stops at the next line of the current function or its callers.
+func (d *Debugger) StepOver() {
+  d.mode = stepOver
+}
+// StepOut stops once the current function returns.
+func (d *Debugger) StepOut() {
+  d.mode = stepOut
+}
+
+// breakpointAt matches breakpoint files by path suffix, so lib.yasp:3
+// stops in an imported /path/to/lib.yasp.
+func (d *Debugger) breakpointAt(pos Position) bool {
+  for file, lines := range d.breakpoints {
+    if lines[pos.Line] && (file == pos.File || strings.HasSuffix(filepath.ToSlash(pos.File), "/" + filepath.ToSlash(file))) { return true }
+  }
+
+  return false
+}
+
+// enter is called when a call is pushed, so a breakpoint stops on every
+// call of a function, also when the previous one ran the same line.
+func (d *Debugger) enter(depth int) {
+  if len(d.lines) > depth { d.lines = d.lines[:depth] }
+}
+
+// before is called by Stack.Evaluate for every expression.
+func (d *Debugger) before(s *Stack, context *EvaluationContext) {
+  if d.paused || !s.Pos.IsValid() { return }
+
+  pos, depth := s.Pos, context.calls.Depth()
+
+  // lines of returned calls are forgotten
+  if len(d.lines) > depth + 1 { d.lines = d.lines[:depth + 1] }
+  for len(d.lines) <= depth { d.lines = append(d.lines, Position{}) }
+
+  last := d.lines[depth]
+  newLine := pos.File != last.File || pos.Line != last.Line
+  d.lines[depth] = pos
+
+  sameLine := pos.File == d.from.File && pos.Line == d.from.Line && depth == d.depth
+
+  reason := ""
+  switch {
+  case d.mode == stepIn && !sameLine: reason = "step"
+  case d.mode == stepOver && depth <= d.depth && !sameLine: reason = "step"
+  case d.mode == stepOut && depth < d.depth: reason = "step"
+  case newLine && d.breakpointAt(pos): reason = "breakpoint"
+  default: return
+  }
+
+  d.mode, d.depth, d.from = stepContinue, depth, pos
+
+  if d.OnPause == nil { return }
+
+  frames := make([]Frame, depth)
+  for i := range frames {
+    frames[i] = context.calls.Frames[depth - 1 - i]
+  }
+
+  // expressions evaluated while inspecting don't stop
+  d.paused = true
+  defer func() { d.paused = false }()
+
+  d.OnPause(&Pause{Pos: pos, Expression: &Value{T: TypeExpression, V: s, Pos: pos}, Context: context, Frames: frames, Reason: reason})
+}
+
+// Eval evaluates source in the paused scope, errors are returned as values.
+func (p *Pause) Eval(source string) (result *Value) {
+  parsing, err := Parse(source, "<debug>")
+  if err != nil { return &Value{T: TypeError, V: &ErrorValue{Message: err.Error()}} }
+  if len(parsing.Forms()) == 0 { return &Value{T: TypeError, V: &ErrorValue{Message: "expected an expression"}} }
+
+  depth := p.Context.calls.Depth()
+
+  defer func() {
+    if r := recover(); r != nil { result = recovered(r, p.Context.calls.unwind(depth)) }
+  }()
+
+  return parsing.Evaluate(p.Context)
+}
+
+// Locals returns names bound in scopes of the paused expression, innermost
+// first, without the root scope holding the prelude.
+func (p *Pause) Locals() []map[string]*Value {
+  scopes := []map[string]*Value{}
+
+  for cur := p.Context; cur != nil && cur.Parent != nil; cur = cur.Parent {
+    scopes = append(scopes, cur.Vars)
+  }
+
+  return scopes
+}
+
+func (p *Pause) String() string {
+  return fmt.Sprintf("%v: %v %v", p.Pos, p.Reason, p.Expression.Print())
+}
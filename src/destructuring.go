@@ -0,0 +1,144 @@
+package yasp
+
+import ( "fmt" )
+
+// Destructure binds names of the pattern to parts of the value:
+//   name          whole value, _ ignores it
+//   (a b & rest)  list elements, rest gets the remaining list
+//   (Point x y)   struct fields in order of definition
+//   (Point :y y)  struct fields by name
+// and literals that must be equal to the value:
+//   1, 'text', (quote symbol), (Color RED)
+func Destructure(context *EvaluationContext, pattern *Value, value *Value) {
+  if err := destructure(context, pattern, value); err != nil { panic(err.Error()) }
+}
+
+func destructure(context *EvaluationContext, pattern *Value, value *Value) error {
+  switch pattern.T {
+  case TypeID: {
+    name := pattern.AssertIdType()
+    if name != "_" { context.Define(name, value) }
+
+    return nil
+  }
+  case TypeNumber, TypeString: return expectEqual(pattern, pattern, value)
+  case TypeExpression: {
+    elements := pattern.AssertExpressionType().Expand()
+
+    if len(elements) == 2 && elements[0].T == TypeID && elements[0].AssertIdType() == "quote" {
+      return expectEqual(pattern, elements[1], value)
+    }
+
+    if et := enumPatternType(context, elements); et != nil {
+      return expectEqual(pattern, et.Construct(elements[1]), value)
+    }
+
+    if st := structPatternType(context, elements); st != nil {
+      return destructureStruct(context, pattern, st, elements[1:], value)
+    }
+
+    return destructureList(context, pattern, elements, value)
+  }
+  default: return fmt.Errorf("invalid pattern: %v", pattern)
+  }
+}
+
+func expectEqual(pattern *Value, expected *Value, value *Value) error {
+  if !expected.Equals(value) { return fmt.Errorf("%v does not match %v", value, pattern) }
+
+  return nil
+}
+
+func enumPatternType(context *EvaluationContext, elements []*Value) *EnumType {
+  if len(elements) != 2 || elements[0].T != TypeID { return nil }
+
+  definition, ok := context.Lookup(elements[0].AssertIdType())
+  if !ok || definition.T != TypeEnumType { return nil }
+
+  return definition.AssertEnumTypeType()
+}
+
+func structPatternType(context *EvaluationContext, elements []*Value) *StructType {
+  if len(elements) == 0 || elements[0].T != TypeID { return nil }
+
+  definition, ok := context.Lookup(elements[0].AssertIdType())
+  if !ok || definition.T != TypeStructType { return nil }
+
+  return definition.AssertStructTypeType()
+}
+
+func destructureList(context *EvaluationContext, pattern *Value, elements []*Value, value *Value) error {
+  var rest *Value
+
+  for i, x := range elements {
+    if x.T == TypeID && x.AssertIdType() == "&" {
+      if i != len(elements) - 2 { return fmt.Errorf("& must be followed by exactly one pattern in %v", pattern) }
+
+      rest = elements[i + 1]
+      elements = elements[:i]
+      break
+    }
+  }
+
+  var lst []*Value
+
+  switch value.T {
+  case TypeList: lst = value.AssertListType()
+  case TypeNil:
+  default: return fmt.Errorf("cannot destructure %v with %v: expected list", value, pattern)
+  }
+
+  if len(lst) < len(elements) || (rest == nil && len(lst) != len(elements)) {
+    expected := fmt.Sprint(len(elements))
+    if rest != nil { expected = "at least " + expected }
+
+    return fmt.Errorf("cannot destructure %v with %v: expected list of %v elements, got %v", value, pattern, expected, len(lst))
+  }
+
+  for i, x := range elements {
+    if err := destructure(context, x, lst[i]); err != nil { return err }
+  }
+
+  if rest != nil {
+    return destructure(context, rest, &Value{T: TypeList, V: lst[len(elements):]})
+  }
+
+  return nil
+}
+
+func destructureStruct(context *EvaluationContext, pattern *Value, st *StructType, fields []*Value, value *Value) error {
+  if value.T != TypeStruct || value.AssertStructType().Type != st {
+    return fmt.Errorf("cannot destructure %v with %v: expected %v", value, pattern, st.Name)
+  }
+
+  sv := value.AssertStructType()
+
+  if len(fields) > 0 && fields[0].T == TypeID && isKeyword(fields[0].AssertIdType()) {
+    if len(fields) % 2 != 0 { return fmt.Errorf("struct pattern %v must contain :field pattern pairs", pattern) }
+
+    for i := 0; i < len(fields); i += 2 {
+      name := fields[i].AssertIdType()
+      if !isKeyword(name) || st.FieldIndex(name[1:]) < 0 {
+        return fmt.Errorf("%v has no field %v in pattern %v", st.Name, name, pattern)
+      }
+
+      if err := destructure(context, fields[i + 1], sv.Get(name[1:])); err != nil { return err }
+    }
+
+    return nil
+  }
+
+  if len(fields) != len(st.Fields) {
+    return fmt.Errorf("cannot destructure %v with %v: %v has %v fields, pattern has %v", value, pattern, st.Name, len(st.Fields), len(fields))
+  }
+
+  for i, x := range fields {
+    if err := destructure(context, x, sv.Values[i]); err != nil { return err }
+  }
+
+  return nil
+}
+
+func isKeyword(name string) bool {
+  return len(name) > 1 && name[0] == ':'
+}
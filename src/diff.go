@@ -0,0 +1,104 @@
+package yasp
+
+import (
+  "fmt"
+  "strings"
+)
+
+// Diff compares texts line by line and returns changed lines in unified
+// format without context, empty when they are equal.
+func Diff(name string, a string, b string) string {
+  if a == b { return "" }
+
+  as, bs := strings.SplitAfter(a, "\n"), strings.SplitAfter(b, "\n")
+
+  // common prefix and suffix are matched directly, the middle by LCS
+  prefix := 0
+  for prefix < len(as) && prefix < len(bs) && as[prefix] == bs[prefix] { prefix++ }
+
+  suffix := 0
+  for suffix < len(as) - prefix && suffix < len(bs) - prefix && as[len(as) - 1 - suffix] == bs[len(bs) - 1 - suffix] { suffix++ }
+
+  matches := [][2]int{}
+  for i := 0; i < prefix; i++ { matches = append(matches, [2]int{i, i}) }
+
+  matches = commonLines(as, bs, prefix, len(as) - suffix, prefix, len(bs) - suffix, matches)
+
+  for i := suffix; i > 0; i-- { matches = append(matches, [2]int{len(as) - i, len(bs) - i}) }
+
+  var buffer strings.Builder
+
+  fmt.Fprintf(&buffer, "--- %v\n+++ %v (formatted)\n", name, name)
+
+  // a hunk of removed and added lines before each match and the end
+  i, j := 0, 0
+  for _, m := range append(matches, [2]int{len(as), len(bs)}) {
+    if m[0] > i || m[1] > j {
+      fmt.Fprintf(&buffer, "@@ -%v,%v +%v,%v @@\n", i + 1, m[0] - i, j + 1, m[1] - j)
+
+      for _, line := range as[i:m[0]] { buffer.WriteString("-" + strings.TrimSuffix(line, "\n") + "\n") }
+      for _, line := range bs[j:m[1]] { buffer.WriteString("+" + strings.TrimSuffix(line, "\n") + "\n") }
+    }
+
+    i, j = m[0] + 1, m[1] + 1
+  }
+
+  return buffer.String()
+}
+
+// commonLines appends index pairs of a longest common subsequence of
+// as[alo:ahi] and bs[blo:bhi]. It splits as in half and finds where to
+// split bs from LCS lengths of both halves (Hirschberg), so it needs
+// linear space.
+func commonLines(as []string, bs []string, alo int, ahi int, blo int, bhi int, matches [][2]int) [][2]int {
+  if alo == ahi || blo == bhi { return matches }
+
+  if ahi - alo == 1 {
+    for j := blo; j < bhi; j++ {
+      if as[alo] == bs[j] { return append(matches, [2]int{alo, j}) }
+    }
+
+    return matches
+  }
+
+  mid := (alo + ahi) / 2
+  forward := lcsLengths(as[alo:mid], bs[blo:bhi], false)
+  backward := lcsLengths(as[mid:ahi], bs[blo:bhi], true)
+
+  // forward[j] + backward[n - j] is the LCS length when bs splits at j
+  split, best := 0, -1
+  for j := 0; j <= bhi - blo; j++ {
+    if length := forward[j] + backward[bhi - blo - j]; length > best { split, best = j, length }
+  }
+
+  matches = commonLines(as, bs, alo, mid, blo, blo + split, matches)
+
+  return commonLines(as, bs, mid, ahi, blo + split, bhi, matches)
+}
+
+// lcsLengths returns LCS lengths of as and each prefix of bs, or of their
+// reversals, by the length of the prefix.
+func lcsLengths(as []string, bs []string, reversed bool) []int {
+  row := make([]int, len(bs) + 1)
+
+  for i := range as {
+    a := as[i]
+    if reversed { a = as[len(as) - 1 - i] }
+
+    diagonal := 0
+    for j := 1; j <= len(bs); j++ {
+      b := bs[j - 1]
+      if reversed { b = bs[len(bs) - j] }
+
+      above := row[j]
+      if a == b {
+        row[j] = diagonal + 1
+      } else if row[j - 1] > row[j] {
+        row[j] = row[j - 1]
+      }
+      diagonal = above
+    }
+  }
+
+  return row
+}
@@ -0,0 +1,44 @@
+package yasp
+
+import ( "fmt" )
+
+func CreateEnumType(name string, variants []*Value) *EnumType {
+  et := &EnumType{Name: name}
+
+  for _, x := range variants {
+    et.Variants = append(et.Variants, x.AssertIdType())
+  }
+
+  return et
+}
+
+func (et *EnumType) VariantIndex(name string) int {
+  for i, variant := range et.Variants {
+    if variant == name { return i }
+  }
+
+  return -1
+}
+
+// Construct takes unevaluated variant name: (Color RED)
+func (et *EnumType) Construct(variant *Value) *Value {
+  name := variant.AssertIdType()
+
+  i := et.VariantIndex(name)
+  if i < 0 { panic(fmt.Sprintf("%v has no variant %v", et.Name, name)) }
+
+  return &Value{T: TypeEnum, V: &EnumValue{Type: et, Index: i}}
+}
+
+func (ev *EnumValue) Variant() string {
+  return ev.Type.Variants[ev.Index]
+}
+
+// switchKeyMatches allows bare variant names as switch keys.
+func switchKeyMatches(key *Value, xv *Value) bool {
+  if xv.T == TypeEnum && key.T == TypeID {
+    return xv.AssertEnumType().Variant() == key.AssertIdType()
+  }
+
+  return key.Equals(xv)
+}
@@ -0,0 +1,162 @@
+package yasp
+
+import (
+  "bytes"
+  "fmt"
+)
+
+type ErrorValue struct {
+  Message string
+  Data *Value
+  Trace []Frame
+}
+
+// Exception carries value of (throw x) through the Go stack.
+type Exception struct {
+  Value *Value
+}
+
+func (e *Exception) Error() string {
+  if e.Value.T == TypeError { return e.Value.AssertErrorType().Message }
+
+  return e.Value.String()
+}
+
+// recovered converts a panic payload into the value seen by catch:
+// thrown values are kept as is, interpreter failures become errors.
+func recovered(r interface{}, trace []Frame) *Value {
+  var ev *ErrorValue
+
+  switch x := r.(type) {
+  case *Exception: {
+    if x.Value.T != TypeError { return x.Value }
+
+    ev = x.Value.AssertErrorType()
+  }
+  case error: ev = &ErrorValue{Message: x.Error()}
+  default: ev = &ErrorValue{Message: fmt.Sprint(x)}
+  }
+
+  if ev.Trace == nil { ev.Trace = trace }
+
+  return &Value{T: TypeError, V: ev}
+}
+
+// evaluateTry evaluates
+//   (try body... (catch pattern handler...) (finally cleanup...))
+// where both catch and finally are optional.
+func evaluateTry(context *EvaluationContext, forms []*Value) *Value {
+  var catch, finally []*Value
+
+  for len(forms) > 0 {
+    last := forms[len(forms) - 1]
+    if last.T != TypeExpression || last.AssertExpressionType().Size == 0 { break }
+
+    clause := last.AssertExpressionType().Expand()
+    if clause[0].T != TypeID { break }
+
+    name := clause[0].AssertIdType()
+
+    if name == "finally" && finally == nil && catch == nil {
+      finally = clause[1:]
+    } else if name == "catch" && catch == nil {
+      if len(clause) < 2 { panic("catch expected a pattern to bind the error") }
+
+      catch = clause[1:]
+    } else {
+      break
+    }
+
+    forms = forms[:len(forms) - 1]
+  }
+
+  if finally != nil {
+    defer evaluateForms(context.Extend(), finally)
+  }
+
+  return evaluateCatching(context, forms, catch)
+}
+
+func evaluateCatching(context *EvaluationContext, body []*Value, catch []*Value) (result *Value) {
+  if catch != nil {
+    depth := context.calls.Depth()
+
+    defer func() {
+      r := recover()
+      if r == nil { return }
+
+      thrown := recovered(r, context.calls.unwind(depth))
+
+      catchContext := context.Extend()
+      Destructure(catchContext, catch[0], thrown)
+
+      result = evaluateForms(catchContext, catch[1:])
+    }()
+  }
+
+  return evaluateForms(context.Extend(), body)
+}
+
+func evaluateForms(context *EvaluationContext, forms []*Value) *Value {
+  last := &Value{T: TypeNil}
+
+  for _, x := range forms {
+    last = x.Evaluate(context)
+  }
+
+  return last
+}
+
+// RuntimeError is an error the program didn't catch, Trace lists the
+// calls it went through, innermost first.
+type RuntimeError struct {
+  Value *Value
+  Trace []Frame
+}
+
+func (e *RuntimeError) Error() string {
+  var buffer bytes.Buffer
+
+  if e.Value.T == TypeError {
+    buffer.WriteString("error: " + e.Value.AssertErrorType().Message)
+  } else {
+    buffer.WriteString("uncaught: " + e.Value.Print())
+  }
+
+  for _, frame := range e.Trace {
+    buffer.WriteString("\n  in " + frame.Describe())
+  }
+
+  return buffer.String()
+}
+
+// Run evaluates the value, returning uncaught errors and thrown values as
+// *RuntimeError instead of panicking.
+func (v *Value) Run(context *EvaluationContext) (result *Value, err error) {
+  depth := context.calls.Depth()
+
+  defer func() {
+    r := recover()
+    if r == nil { return }
+
+    // a rethrown error continues the trace of where it was first thrown
+    var trace []Frame
+    if x, ok := r.(*Exception); ok && x.Value.T == TypeError { trace = append(trace, x.Value.AssertErrorType().Trace...) }
+    trace = append(trace, context.calls.unwind(depth)...)
+
+    thrown := recovered(r, trace)
+
+    result, err = nil, &RuntimeError{Value: thrown, Trace: trace}
+  }()
+
+  return v.Evaluate(context), nil
+}
+
+// Run evaluates all forms, stopping at the first uncaught error.
+func (p *Parsing) Run(context *EvaluationContext) (result *Value, err error) {
+  for _, x := range p.Forms() {
+    if result, err = x.Run(context); err != nil { return nil, err }
+  }
+
+  return result, nil
+}
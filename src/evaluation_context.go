@@ -2,15 +2,55 @@ package yasp
 
 type EvaluationContext struct {
   Vars map[string]*Value
+  Parent *EvaluationContext
+  // Strict makes unbound identifiers an error instead of a symbol
+  Strict bool
+  // Debugger is notified before expressions are evaluated, nil when not debugging
+  Debugger *Debugger
+
+  calls *CallStack
+  modules *Modules
+  module *Module
 }
+// EmptyEvaluationContext creates a context with the prelude loaded
+// into the parent scope, so user definitions can shadow it.
 func EmptyEvaluationContext() *EvaluationContext {
-  return &EvaluationContext{Vars: make(map[string]*Value)}
+  context := BareEvaluationContext()
+  loadPrelude(context)
+
+  return context.Extend()
+}
+// BareEvaluationContext creates a context with builtins only.
+func BareEvaluationContext() *EvaluationContext {
+  return &EvaluationContext{Vars: make(map[string]*Value), calls: &CallStack{}, modules: newModules()}
+}
+
+// Extend creates a nested scope. Definitions go to the new scope,
+// while set! updates the scope where the name was bound.
+func (c *EvaluationContext) Extend() *EvaluationContext {
+  return &EvaluationContext{Vars: make(map[string]*Value), Parent: c, Strict: c.Strict,
+                            Debugger: c.Debugger, calls: c.calls, modules: c.modules, module: c.module}
+}
+
+func (c *EvaluationContext) Lookup(name string) (*Value, bool) {
+  for cur := c; cur != nil; cur = cur.Parent {
+    if value, ok := cur.Vars[name]; ok { return value, true }
+  }
+
+  return c.lookupQualified(name)
 }
 
-func (c *EvaluationContext) Clone() *EvaluationContext {
-  newContext := EmptyEvaluationContext()
-  for key, value := range c.Vars {
-    newContext.Vars[key] = value
+func (c *EvaluationContext) Define(name string, value *Value) {
+  c.Vars[name] = value
+}
+
+func (c *EvaluationContext) Set(name string, value *Value) bool {
+  for cur := c; cur != nil; cur = cur.Parent {
+    if _, ok := cur.Vars[name]; ok {
+      cur.Vars[name] = value
+      return true
+    }
   }
-  return newContext
+
+  return false
 }
@@ -0,0 +1,334 @@
+package yasp
+
+import (
+  "sort"
+  "strings"
+  "unicode/utf8"
+)
+
+// Format rewrites the source with the standard layout. Forms that fit in
+// width stay on one line, others are broken with indentation rules per
+// special form. Comments are kept, literals are copied as written.
+func Format(source string, file string, width int) (string, error) {
+  parsing, err := Parse(source, file)
+  if err != nil { return "", err }
+
+  f := &formatter{source: []rune(source), text: source, parsing: parsing, width: width}
+
+  f.comments = append([]Comment{}, parsing.Comments...)
+  sort.SliceStable(f.comments, func (i, j int) bool { return f.comments[i].Pos.Offset < f.comments[j].Pos.Offset })
+
+  b := &block{f: f, out: &strings.Builder{}}
+
+  for _, form := range parsing.Forms() {
+    b.put(form, false, func (col int) string { return f.form(form, col) })
+  }
+  b.commentsBefore(len(f.source))
+
+  if b.out.Len() == 0 { return "", nil }
+
+  return b.out.String() + "\n", nil
+}
+
+// formHeaders is the number of arguments kept on the line of the special
+// form name when it is broken, the rest is indented by 2.
+var formHeaders = map[string]int{
+  "defn": 2, "fn": 1, "def": 1, "let": 1, "if": 1, "switch": 1, "match": 1,
+  "defstruct": 1, "defenum": 1, "module": 1, "import": 1, "try": 0,
+}
+
+type formatter struct {
+  source []rune
+  text string
+  parsing *Parsing
+  width int
+
+  // comments sorted by offset, next is the first one not written yet
+  comments []Comment
+  next int
+}
+
+func (f *formatter) end(v *Value) int {
+  return f.parsing.Spans[v.Pos.Offset]
+}
+func (f *formatter) line(offset int) int {
+  return f.parsing.Locate(f.text, offset).Line
+}
+func (f *formatter) verbatim(v *Value) string {
+  return string(f.source[v.Pos.Offset:f.end(v)])
+}
+
+// isList tells lists written with braces from atoms, interpolated strings
+// are expressions too but are copied as written.
+func (f *formatter) isList(v *Value) bool {
+  return v.T == TypeExpression && f.source[v.Pos.Offset] == '('
+}
+
+func (f *formatter) hasComments(start int, end int) bool {
+  i := sort.Search(len(f.comments), func (i int) bool { return f.comments[i].Pos.Offset > start })
+
+  return i < len(f.comments) && f.comments[i].Pos.Offset < end
+}
+
+// takeComments returns comments before the offset not written yet,
+// skipping ones inside ignored #_ expressions.
+func (f *formatter) takeComments(offset int) []Comment {
+  taken := []Comment{}
+  skipUntil := -1
+
+  for ; f.next < len(f.comments) && f.comments[f.next].Pos.Offset < offset; f.next++ {
+    c := f.comments[f.next]
+    if c.Pos.Offset < skipUntil { continue }
+
+    if strings.HasPrefix(c.Text, "#_") { skipUntil = f.parsing.Spans[c.Pos.Offset] }
+
+    taken = append(taken, c)
+  }
+
+  return taken
+}
+func (f *formatter) commentText(c Comment) string {
+  if end, ok := f.parsing.Spans[c.Pos.Offset]; ok && strings.HasPrefix(c.Text, "#_") {
+    return string(f.source[c.Pos.Offset:end])
+  }
+
+  return c.Text
+}
+func (f *formatter) commentEnd(c Comment) int {
+  return c.Pos.Offset + utf8.RuneCountInString(f.commentText(c))
+}
+
+// flat returns the one line layout, not possible with comments or
+// multiline literals inside.
+func (f *formatter) flat(v *Value) (string, bool) {
+  if !f.isList(v) {
+    text := f.verbatim(v)
+    return text, !strings.Contains(text, "\n")
+  }
+
+  if f.hasComments(v.Pos.Offset, f.end(v)) { return "", false }
+
+  elems := v.AssertExpressionType().Expand()
+  texts := make([]string, len(elems))
+
+  for i, x := range elems {
+    text, ok := f.flat(x)
+    if !ok { return "", false }
+
+    texts[i] = text
+  }
+
+  return "(" + strings.Join(texts, " ") + ")", true
+}
+
+// fits reports whether the value fits on one line starting at col.
+func (f *formatter) fits(v *Value, col int) (string, bool) {
+  text, ok := f.flat(v)
+
+  return text, ok && col + utf8.RuneCountInString(text) <= f.width
+}
+
+// form lays out the value starting at column col, following lines are
+// indented absolutely.
+func (f *formatter) form(v *Value, col int) string {
+  if !f.isList(v) {
+    // comments inside interpolations are written with the string
+    for f.next < len(f.comments) && f.comments[f.next].Pos.Offset < f.end(v) { f.next++ }
+
+    return f.verbatim(v)
+  }
+  if text, ok := f.fits(v, col); ok { return text }
+
+  elems := v.AssertExpressionType().Expand()
+
+  name := ""
+  if len(elems) > 0 && elems[0].T == TypeID { name = f.verbatim(elems[0]) }
+
+  headers, special := formHeaders[name]
+  indent := col + 2
+
+  switch {
+  case name == "if": indent = col + 4
+  case special:
+  case name != "": {
+    // calls align arguments under the first one
+    headers = 1
+    if col + utf8.RuneCountInString(name) + 2 < f.width / 2 { indent = col + utf8.RuneCountInString(name) + 2 }
+  }
+  default: indent, headers = col + 1, 0
+  }
+
+  b := &block{f: f, out: &strings.Builder{}, indent: indent, col: col + 1, line: f.line(v.Pos.Offset)}
+  b.out.WriteString("(")
+
+  // arguments of calls with atoms only are filled up to the width
+  fill := name != "" && !special
+  for _, x := range elems[1:] {
+    if f.isList(x) { fill = false }
+  }
+
+  for i := 0; i < len(elems); i++ {
+    x := elems[i]
+    inline := i <= headers && !f.hasComments(v.Pos.Offset, x.Pos.Offset)
+
+    if text, ok := f.fits(x, b.col + 1); fill && ok && b.col + 1 + utf8.RuneCountInString(text) < f.width {
+      inline = true
+    }
+
+    switch {
+    case name == "let" && i == 1 && f.isList(x): {
+      b.put(x, inline, func (col int) string { return f.bindings(x, col) })
+    }
+    case name == "switch" && i > headers && i + 1 < len(elems): {
+      key, value := x, elems[i + 1]
+      b.putPair(key, value, inline, -1)
+      i++
+    }
+    default: b.put(x, inline, func (col int) string { return f.form(x, col) })
+    }
+  }
+
+  b.close(f.end(v) - 1, col)
+
+  return b.out.String()
+}
+
+// bindings aligns values of name value pairs.
+func (f *formatter) bindings(v *Value, col int) string {
+  elems := v.AssertExpressionType().Expand()
+
+  if text, ok := f.fits(v, col); ok && len(elems) <= 2 { return text }
+
+  nameWidth := 0
+  for i := 0; i < len(elems); i += 2 {
+    if text, ok := f.flat(elems[i]); ok && utf8.RuneCountInString(text) > nameWidth {
+      nameWidth = utf8.RuneCountInString(text)
+    }
+  }
+
+  b := &block{f: f, out: &strings.Builder{}, indent: col + 1, col: col + 1, line: f.line(v.Pos.Offset)}
+  b.out.WriteString("(")
+
+  for i := 0; i < len(elems); i += 2 {
+    inline := i == 0 && !f.hasComments(v.Pos.Offset, elems[i].Pos.Offset)
+
+    if i + 1 < len(elems) {
+      b.putPair(elems[i], elems[i + 1], inline, nameWidth)
+    } else {
+      x := elems[i]
+      b.put(x, inline, func (col int) string { return f.form(x, col) })
+    }
+  }
+
+  b.close(f.end(v) - 1, col)
+
+  return b.out.String()
+}
+
+// block writes elements of a list one per line, with comments between
+// them. Comments on the line where the previous element ended stay there.
+type block struct {
+  f *formatter
+  out *strings.Builder
+  indent int
+
+  // output column and source line of the last written element
+  col int
+  line int
+  lineComment bool
+}
+
+func (b *block) write(s string) {
+  b.out.WriteString(s)
+
+  if i := strings.LastIndex(s, "\n"); i >= 0 {
+    b.col = utf8.RuneCountInString(s[i + 1:])
+  } else {
+    b.col += utf8.RuneCountInString(s)
+  }
+}
+
+// newline starts a line for source line next, one blank line is kept.
+func (b *block) newline(next int) {
+  if b.out.Len() > 0 {
+    b.out.WriteString("\n")
+    if next - b.line > 1 { b.out.WriteString("\n") }
+  }
+
+  b.col = 0
+  b.write(strings.Repeat(" ", b.indent))
+}
+
+func (b *block) commentsBefore(offset int) {
+  for _, c := range b.f.takeComments(offset) {
+    if c.Pos.Line == b.line && b.out.Len() > 0 {
+      b.write(" ")
+    } else {
+      b.newline(c.Pos.Line)
+    }
+
+    text := b.f.commentText(c)
+    b.write(text)
+
+    b.line = b.f.line(b.f.commentEnd(c))
+    b.lineComment = strings.HasPrefix(text, "//")
+  }
+}
+
+// put writes the element on the current line when inline, otherwise
+// on a new one.
+func (b *block) put(v *Value, inline bool, render func (col int) string) {
+  b.commentsBefore(v.Pos.Offset)
+
+  if inline && !b.lineComment {
+    if !strings.HasSuffix(b.out.String(), "(") { b.write(" ") }
+  } else {
+    b.newline(b.f.line(v.Pos.Offset))
+  }
+
+  b.write(render(b.col))
+  b.line = b.f.line(b.f.end(v))
+  b.lineComment = false
+}
+
+// putPair writes key and value on one line, the key padded to keyWidth,
+// or the value on the next line indented when it doesn't fit.
+func (b *block) putPair(key *Value, value *Value, inline bool, keyWidth int) {
+  b.put(key, inline, func (col int) string { return b.f.form(key, col) })
+
+  if b.f.hasComments(key.Pos.Offset, value.Pos.Offset) {
+    saved := b.indent
+    b.indent += 2
+    b.put(value, false, func (col int) string { return b.f.form(value, col) })
+    b.indent = saved
+    return
+  }
+
+  if pad := keyWidth - (b.col - b.indent); keyWidth > 0 && pad > 0 { b.write(strings.Repeat(" ", pad)) }
+
+  if _, ok := b.f.fits(value, b.col + 1); ok || keyWidth > 0 || !b.f.isList(value) {
+    b.write(" ")
+    b.write(b.f.form(value, b.col))
+  } else {
+    saved := b.indent
+    b.indent += 2
+    b.newline(b.line)
+    b.write(b.f.form(value, b.col))
+    b.indent = saved
+  }
+
+  b.line = b.f.line(b.f.end(value))
+}
+
+// close writes comments before the closing brace at offset and the brace,
+// on its own line after a line comment.
+func (b *block) close(offset int, col int) {
+  b.commentsBefore(offset)
+
+  if b.lineComment {
+    b.indent = col
+    b.newline(b.line)
+  }
+
+  b.write(")")
+}
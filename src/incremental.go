@@ -0,0 +1,146 @@
+package yasp
+
+import (
+  "errors"
+  "io"
+  "strings"
+)
+
+// ErrIncomplete is returned by IncrementalReader when the input so far
+// ends inside a form.
+var ErrIncomplete = errors.New("needs more input")
+
+// errStopped ends the reader goroutine after Stop.
+var errStopped = errors.New("reader stopped")
+
+// IncrementalReader accepts the source chunk by chunk and yields top level
+// forms as soon as they are complete, for REPLs and pipes. Its Reader runs
+// in a goroutine that pauses when it needs more input, so every chunk is
+// read once. The goroutine ends when Next returns io.EOF or on Stop, a
+// reader given up before the end of input has to be stopped.
+type IncrementalReader struct {
+  File string
+  Comments []Comment
+
+  reader *Reader
+  input feed
+  started bool
+  done bool
+
+  // byte offsets of the end of the last form and of the last input fed
+  // that isn't whitespace
+  formEnd int
+  textEnd int
+  fed int
+}
+
+type readResult struct {
+  form *Value
+  err error
+}
+
+// feed is the input of the reader, when it runs out of chunks the reader
+// hands ErrIncomplete to Next and waits for resume or stop.
+type feed struct {
+  pending []byte
+  closed bool
+
+  results chan readResult
+  resume chan struct{}
+  stop chan struct{}
+}
+
+func (f *feed) Read(p []byte) (int, error) {
+  for len(f.pending) == 0 {
+    if f.closed { return 0, io.EOF }
+
+    f.results <- readResult{err: ErrIncomplete}
+    if !f.wait() { return 0, errStopped }
+  }
+
+  n := copy(p, f.pending)
+  f.pending = f.pending[n:]
+
+  return n, nil
+}
+
+// wait reports whether the reader is resumed rather than stopped.
+func (f *feed) wait() bool {
+  select {
+  case <-f.resume: return true
+  case <-f.stop: return false
+  }
+}
+
+func NewIncrementalReader(file string) *IncrementalReader {
+  r := &IncrementalReader{File: file}
+  r.input.results = make(chan readResult)
+  r.input.resume = make(chan struct{})
+  r.input.stop = make(chan struct{})
+  r.reader = NewReader(&r.input, file)
+
+  return r
+}
+
+// Feed appends a chunk of the source.
+func (r *IncrementalReader) Feed(chunk string) {
+  r.input.pending = append(r.input.pending, chunk...)
+
+  if text := strings.TrimRight(chunk, " \t\r\n"); text != "" { r.textEnd = r.fed + len(text) }
+  r.fed += len(chunk)
+}
+
+// Close marks the end of input, Next then reports unfinished forms as errors.
+func (r *IncrementalReader) Close() {
+  r.input.closed = true
+}
+
+// Stop ends the reader goroutine, Next returns io.EOF after it.
+func (r *IncrementalReader) Stop() {
+  if r.done { return }
+
+  r.done = true
+  close(r.input.stop)
+}
+
+// Pending reports whether a started form waits for more input.
+func (r *IncrementalReader) Pending() bool {
+  return r.textEnd > r.formEnd
+}
+
+// Next returns the next complete form, ErrIncomplete when it needs more
+// input and io.EOF after Close. A form is complete once the character
+// after it is fed, "(a)b" is an error. After a syntax error reading
+// continues with the next form.
+func (r *IncrementalReader) Next() (*Value, error) {
+  if r.done { return nil, io.EOF }
+
+  if r.started {
+    r.input.resume <- struct{}{}
+  } else {
+    r.started = true
+    go r.read()
+  }
+
+  result := <-r.input.results
+  r.done = result.err == io.EOF
+
+  return result.form, result.err
+}
+
+// read runs in the goroutine of the reader, Next resumes it. Forms are
+// discarded once handed out.
+func (r *IncrementalReader) read() {
+  for {
+    form, err := r.reader.ReadForm()
+    if err == errStopped { return }
+
+    r.formEnd = r.reader.consumed
+    r.Comments = r.reader.Comments
+    r.input.results <- readResult{form, err}
+
+    if err == io.EOF || !r.input.wait() { return }
+
+    r.reader.discard()
+  }
+}
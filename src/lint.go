@@ -0,0 +1,524 @@
+package yasp
+
+import (
+  "fmt"
+  "sort"
+  "strings"
+)
+
+// Lint rules, all are enabled by default.
+const (
+  RuleSyntax = "syntax"
+  RuleArity = "arity"
+  RuleUnbound = "unbound"
+  RuleLetBindings = "let-bindings"
+  RuleSwitchDefault = "switch-default"
+  RuleShadowedBuiltin = "shadowed-builtin"
+  RuleUnused = "unused"
+)
+
+var LintRules = []string{
+  RuleSyntax, RuleArity, RuleUnbound, RuleLetBindings, RuleSwitchDefault, RuleShadowedBuiltin, RuleUnused,
+}
+
+// Diagnostic is a problem found by Lint, Severity is "error" for code
+// that fails at runtime and "warning" otherwise.
+type Diagnostic struct {
+  Pos Position `json:"pos"`
+  Rule string `json:"rule"`
+  Severity string `json:"severity"`
+  Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+  return fmt.Sprintf("%v: %v: %v [%v]", d.Pos, d.Severity, d.Message, d.Rule)
+}
+
+// LintConfig selects rules, nil Disabled enables all of them.
+type LintConfig struct {
+  Disabled map[string]bool
+}
+
+// Lint checks the source without evaluating it. Names defined by the
+// prelude and builtins are known, others have to be defined in the file.
+func Lint(source string, file string, config LintConfig) []Diagnostic {
+  l := &linter{config: config}
+
+  parsing, err := Parse(source, file)
+  if err != nil {
+    if errs, ok := err.(ParseErrors); ok {
+      for _, e := range errs {
+        if len(e.Expected) == 0 {
+          l.report(e.Pos, RuleSyntax, "error", "%v", e.Message)
+        } else {
+          l.report(e.Pos, RuleSyntax, "error", "%v, expected %v", e.Message, strings.Join(e.Expected, " or "))
+        }
+      }
+    }
+
+    return l.diagnostics
+  }
+
+  scope := l.globals()
+  forms := parsing.Forms()
+
+  // top level definitions can be used before them
+  l.declare(scope, forms)
+
+  for _, form := range forms {
+    l.walk(form, scope)
+  }
+
+  sort.SliceStable(l.diagnostics, func (i, j int) bool { return l.diagnostics[i].Pos.Offset < l.diagnostics[j].Pos.Offset })
+
+  return l.diagnostics
+}
+
+type bindingKind int
+const (
+  bindingVariable bindingKind = iota
+  bindingFunction
+  bindingStruct
+  bindingEnum
+  bindingModule
+)
+
+type lintBinding struct {
+  pos Position
+  kind bindingKind
+  arity Builtin
+  used bool
+}
+
+type lintScope struct {
+  parent *lintScope
+  names map[string]*lintBinding
+  // bindings of the scope are reported when unused
+  local bool
+}
+
+func (s *lintScope) extend() *lintScope {
+  return &lintScope{parent: s, names: make(map[string]*lintBinding), local: true}
+}
+func (s *lintScope) lookup(name string) *lintBinding {
+  for cur := s; cur != nil; cur = cur.parent {
+    if b, ok := cur.names[name]; ok { return b }
+  }
+
+  return nil
+}
+
+type linter struct {
+  config LintConfig
+  diagnostics []Diagnostic
+}
+
+func (l *linter) report(pos Position, rule string, severity string, format string, args ...interface {}) {
+  if l.config.Disabled[rule] { return }
+
+  l.diagnostics = append(l.diagnostics, Diagnostic{Pos: pos, Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+// globals are the prelude definitions, builtins are checked separately.
+func (l *linter) globals() *lintScope {
+  scope := &lintScope{names: make(map[string]*lintBinding)}
+
+  for context := EmptyEvaluationContext(); context != nil; context = context.Parent {
+    for name, value := range context.Vars {
+      b := &lintBinding{kind: bindingVariable, used: true}
+      if value.T == TypeFunction {
+        b.kind, b.arity = bindingFunction, functionArity(value.AssertFunctionType())
+      }
+
+      scope.names[name] = b
+    }
+  }
+
+  return scope.extend()
+}
+
+// define binds the name in the scope, unused bindings of local scopes are
+// reported by close.
+func (l *linter) define(scope *lintScope, name *Value, kind bindingKind) *lintBinding {
+  if name.T != TypeID { return nil }
+
+  id := name.AssertIdType()
+  if id == "_" || isKeyword(id) { return nil }
+
+  if _, ok := Builtins[id]; ok {
+    l.report(name.Pos, RuleShadowedBuiltin, "warning", "%v shadows a builtin", id)
+  }
+
+  b := &lintBinding{pos: name.Pos, kind: kind, used: !scope.local || strings.HasPrefix(id, "_")}
+  scope.names[id] = b
+
+  return b
+}
+
+func (l *linter) close(scope *lintScope) {
+  names := []string{}
+  for name, b := range scope.names {
+    if !b.used { names = append(names, name) }
+  }
+  sort.Strings(names)
+
+  for _, name := range names {
+    l.report(scope.names[name].pos, RuleUnused, "warning", "%v is never used", name)
+  }
+}
+
+// declare defines names of definitions among forms before walking them.
+func (l *linter) declare(scope *lintScope, forms []*Value) {
+  for _, form := range forms {
+    head, elems := l.head(form)
+
+    switch {
+    case len(elems) < 2:
+    case head == "def": l.define(scope, elems[1], bindingVariable)
+    case head == "defn" && len(elems) > 2 && elems[2].T == TypeExpression: {
+      if b := l.define(scope, elems[1], bindingFunction); b != nil {
+        b.arity = parametersArity(elems[2].AssertExpressionType().Expand())
+      }
+    }
+    case head == "defstruct": l.define(scope, elems[1], bindingStruct)
+    case head == "defenum": l.define(scope, elems[1], bindingEnum)
+    case head == "module": l.define(scope, elems[1], bindingModule)
+    case head == "import": l.defineImport(scope, elems)
+    }
+  }
+}
+
+func (l *linter) defineImport(scope *lintScope, elems []*Value) {
+  switch {
+  case len(elems) == 4: l.define(scope, elems[3], bindingModule)
+  case len(elems) == 2 && elems[1].T == TypeString: {
+    path := elems[1].AssertStringType()
+    alias := path[strings.LastIndex(path, "/") + 1:]
+    if i := strings.LastIndex(alias, "."); i > 0 { alias = alias[:i] }
+
+    l.define(scope, &Value{T: TypeID, V: alias, Pos: elems[1].Pos}, bindingModule)
+  }
+  }
+}
+
+// head returns the name of the called identifier and the elements.
+func (l *linter) head(v *Value) (string, []*Value) {
+  if v.T != TypeExpression { return "", nil }
+
+  elems := v.AssertExpressionType().Expand()
+  if len(elems) == 0 || elems[0].T != TypeID { return "", elems }
+
+  return elems[0].AssertIdType(), elems
+}
+
+func (l *linter) use(v *Value, scope *lintScope) *lintBinding {
+  name := v.AssertIdType()
+  if name == "_" || isKeyword(name) { return nil }
+
+  if b := scope.lookup(name); b != nil {
+    b.used = true
+    return b
+  }
+
+  if _, ok := Builtins[name]; ok { return nil }
+
+  if i := strings.Index(name, "/"); i > 0 {
+    if b := scope.lookup(name[:i]); b != nil && b.kind == bindingModule {
+      b.used = true
+      return nil
+    }
+  }
+
+  l.report(v.Pos, RuleUnbound, "error", "%v is not defined", name)
+
+  return nil
+}
+
+func (l *linter) walkAll(forms []*Value, scope *lintScope) {
+  for _, x := range forms {
+    l.walk(x, scope)
+  }
+}
+
+func (l *linter) walk(v *Value, scope *lintScope) {
+  if v.T == TypeID {
+    l.use(v, scope)
+    return
+  }
+  if v.T != TypeExpression { return }
+
+  head, elems := l.head(v)
+  if len(elems) == 0 { return }
+
+  var binding *lintBinding
+  if head != "" { binding = scope.lookup(head) }
+
+  // special forms, unless the name is rebound
+  if builtin, ok := Builtins[head]; ok && binding == nil {
+    if !builtin.Accepts(len(elems) - 1) {
+      l.report(v.Pos, RuleArity, "error", "%v expects %v, got %v", head, builtin, len(elems) - 1)
+    }
+
+    if l.special(head, v, elems, scope) { return }
+
+    l.walkAll(elems[1:], scope)
+    return
+  }
+
+  if head == "" {
+    l.walkAll(elems, scope)
+    return
+  }
+
+  binding = l.use(elems[0], scope)
+  if binding == nil {
+    l.walkAll(elems[1:], scope)
+    return
+  }
+
+  switch binding.kind {
+  case bindingFunction: {
+    if !binding.arity.Accepts(len(elems) - 1) {
+      l.report(v.Pos, RuleArity, "error", "%v expects %v, got %v", head, binding.arity, len(elems) - 1)
+    }
+  }
+  case bindingStruct, bindingEnum: {
+    // field and variant names are not evaluated
+    for _, x := range elems[1:] {
+      if x.T != TypeID { l.walk(x, scope) }
+    }
+    return
+  }
+  }
+
+  l.walkAll(elems[1:], scope)
+}
+
+// special checks forms that bind names or don't evaluate their arguments,
+// it returns false for ordinary calls.
+func (l *linter) special(head string, v *Value, elems []*Value, scope *lintScope) bool {
+  args := elems[1:]
+
+  switch head {
+  case "quote", "export", "defstruct", "defenum": return true
+  case "def": {
+    if len(args) < 2 { return true }
+
+    l.walk(args[1], scope)
+
+    if args[0].T != TypeID {
+      l.report(args[0].Pos, RuleSyntax, "error", "def name must be an identifier, got %v", args[0].Print())
+    } else if scope.names[args[0].AssertIdType()] == nil {
+      l.define(scope, args[0], bindingVariable)
+    }
+  }
+  case "set!": l.walkAll(args, scope)
+  case "let": {
+    if len(args) < 1 { return true }
+
+    if args[0].T != TypeExpression {
+      l.report(args[0].Pos, RuleLetBindings, "error", "let bindings must be a list")
+      return true
+    }
+
+    bindings := args[0].AssertExpressionType().Expand()
+    if len(bindings) % 2 != 0 {
+      l.report(args[0].Pos, RuleLetBindings, "error", "let bindings must be name value pairs, got %v values", len(bindings))
+    }
+
+    letScope := scope.extend()
+    for i := 0; i + 1 < len(bindings); i += 2 {
+      l.walk(bindings[i + 1], letScope)
+      l.bindPattern(bindings[i], letScope)
+    }
+
+    l.walkAll(args[1:], letScope)
+    l.close(letScope)
+  }
+  case "fn": l.function(args, scope)
+  case "defn": {
+    if len(args) < 3 { return true }
+
+    if args[0].T != TypeID {
+      l.report(args[0].Pos, RuleSyntax, "error", "defn name must be an identifier, got %v", args[0].Print())
+    } else if scope.names[args[0].AssertIdType()] == nil {
+      if b := l.define(scope, args[0], bindingFunction); b != nil && args[1].T == TypeExpression {
+        b.arity = parametersArity(args[1].AssertExpressionType().Expand())
+      }
+    }
+
+    l.function(args[1:], scope)
+  }
+  case "switch": {
+    if len(args) < 1 { return true }
+
+    l.walk(args[0], scope)
+
+    cases := args[1:]
+    if len(cases) % 2 == 0 { l.report(v.Pos, RuleSwitchDefault, "warning", "switch has no default branch") }
+
+    for i, x := range cases {
+      // keys can be bare enum variants
+      if i % 2 == 0 && i + 1 < len(cases) && x.T == TypeID { continue }
+
+      l.walk(x, scope)
+    }
+  }
+  case "match": {
+    if len(args) < 1 { return true }
+
+    l.walk(args[0], scope)
+
+    for _, clause := range args[1:] {
+      if clause.T != TypeExpression { continue }
+
+      parts := clause.AssertExpressionType().Expand()
+      if len(parts) == 0 { continue }
+
+      clauseScope := scope.extend()
+      l.bindPattern(parts[0], clauseScope)
+
+      for _, x := range parts[1:] {
+        if x.T == TypeID && x.AssertIdType() == ":when" { continue }
+        l.walk(x, clauseScope)
+      }
+
+      l.close(clauseScope)
+    }
+  }
+  case "try": {
+    for _, x := range args {
+      clause, parts := l.head(x)
+
+      switch {
+      case clause == "catch" && len(parts) > 1: {
+        catchScope := scope.extend()
+        l.bindPattern(parts[1], catchScope)
+        l.walkAll(parts[2:], catchScope)
+        l.close(catchScope)
+      }
+      case clause == "finally": l.walkAll(parts[1:], scope)
+      default: l.walk(x, scope)
+      }
+    }
+  }
+  case "module": {
+    if len(args) < 1 { return true }
+
+    moduleScope := scope.extend()
+    moduleScope.local = false
+    l.declare(moduleScope, args[1:])
+    l.walkAll(args[1:], moduleScope)
+  }
+  case "import": {
+    if len(args) > 0 { l.walk(args[0], scope) }
+    if scope.lookup(importAlias(elems)) == nil { l.defineImport(scope, elems) }
+  }
+  default: return false
+  }
+
+  return true
+}
+
+func importAlias(elems []*Value) string {
+  if len(elems) == 4 && elems[3].T == TypeID { return elems[3].AssertIdType() }
+  if len(elems) != 2 || elems[1].T != TypeString { return "" }
+
+  path := elems[1].AssertStringType()
+  alias := path[strings.LastIndex(path, "/") + 1:]
+  if i := strings.LastIndex(alias, "."); i > 0 { alias = alias[:i] }
+
+  return alias
+}
+
+// function checks (params body) of fn and defn.
+func (l *linter) function(args []*Value, scope *lintScope) {
+  if len(args) < 2 { return }
+
+  fnScope := scope.extend()
+
+  if args[0].T == TypeExpression {
+    for _, x := range args[0].AssertExpressionType().Expand() {
+      if x.T == TypeID && (x.AssertIdType() == "&" || x.AssertIdType() == "&optional" || x.AssertIdType() == "&key") { continue }
+
+      // (pattern default) of optional and key parameters
+      if _, parts := l.head(x); len(parts) == 2 && parts[0].T == TypeID && !l.isPattern(parts, fnScope) {
+        l.walk(parts[1], fnScope)
+        l.bindPattern(parts[0], fnScope)
+        continue
+      }
+
+      l.bindPattern(x, fnScope)
+    }
+  }
+
+  l.walkAll(args[1:], fnScope)
+  l.close(fnScope)
+}
+
+// isPattern tells struct and enum patterns from (name default) pairs.
+func (l *linter) isPattern(parts []*Value, scope *lintScope) bool {
+  b := scope.lookup(parts[0].AssertIdType())
+  return b != nil && (b.kind == bindingStruct || b.kind == bindingEnum)
+}
+
+// bindPattern defines names bound by a destructuring pattern.
+func (l *linter) bindPattern(pattern *Value, scope *lintScope) {
+  switch pattern.T {
+  case TypeID: {
+    if pattern.AssertIdType() != "&" { l.define(scope, pattern, bindingVariable) }
+  }
+  case TypeExpression: {
+    head, elems := l.head(pattern)
+    if head == "quote" { return }
+
+    if b := scope.lookup(head); head != "" && b != nil {
+      switch b.kind {
+      case bindingEnum: {
+        b.used = true
+        return
+      }
+      case bindingStruct: {
+        b.used = true
+        for _, x := range elems[1:] {
+          if x.T == TypeID && isKeyword(x.AssertIdType()) { continue }
+          l.bindPattern(x, scope)
+        }
+        return
+      }
+      }
+    }
+
+    for _, x := range elems {
+      l.bindPattern(x, scope)
+    }
+  }
+  }
+}
+
+// parametersArity counts required and optional parameters, rest and key
+// parameters accept any number of arguments.
+func parametersArity(params []*Value) Builtin {
+  arity := Builtin{}
+  section := "required"
+
+  for _, x := range params {
+    if x.T == TypeID {
+      switch x.AssertIdType() {
+      case "&optional": section = "optional"; continue
+      case "&", "&key": return Builtin{arity.Min, -1}
+      }
+    }
+
+    if section == "required" { arity.Min++ }
+    arity.Max++
+  }
+
+  return arity
+}
+
+func functionArity(fv *ValueFunction) Builtin {
+  if fv.rest != nil || len(fv.keys) > 0 { return Builtin{len(fv.args), -1} }
+
+  return Builtin{len(fv.args), len(fv.args) + len(fv.optional)}
+}
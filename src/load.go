@@ -0,0 +1,18 @@
+package yasp
+
+import (
+  "io/ioutil"
+  "strings"
+)
+
+// Parse returns ParseErrors listing every syntax error in the source.
+func Parse(source string, file string) (*Parsing, error) {
+  return NewReader(strings.NewReader(source), file).Read()
+}
+
+func ParseFile(path string) (*Parsing, error) {
+  source, err := ioutil.ReadFile(path)
+  if err != nil { return nil, err }
+
+  return Parse(string(source), path)
+}
@@ -0,0 +1,35 @@
+//go:build peg
+// +build peg
+
+package yasp
+
+// ParsePEG parses with the parser generated from syntax.peg, the reference
+// for Reader.
+func ParsePEG(source string, file string) (parsing *Parsing, err error) {
+  yaspPeg := &YaspPEG{Buffer: source}
+  yaspPeg.Init()
+  yaspPeg.Parsing.Init()
+  yaspPeg.Parsing.File = file
+
+  if err := yaspPeg.Parse(); err != nil {
+    // the reader reports where and what was expected
+    if _, errs := Parse(source, file); errs != nil { return nil, errs }
+
+    return nil, ParseErrors{&ParseError{Message: err.Error()}}
+  }
+
+  // actions reject malformed literals by panicking
+  defer func() {
+    if r := recover(); r != nil {
+      e, ok := r.(*ParseError)
+      if !ok { panic(r) }
+
+      e.Excerpt = excerpt([]rune(source), e.Pos)
+      parsing, err = nil, ParseErrors{e}
+    }
+  }()
+
+  yaspPeg.Execute()
+
+  return &yaspPeg.Parsing, nil
+}
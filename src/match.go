@@ -0,0 +1,39 @@
+package yasp
+
+import ( "fmt" )
+
+// evaluateMatch evaluates
+//   (match expr
+//     (pattern body)
+//     (pattern :when guard body)
+//     ...)
+// taking the first clause whose pattern matches and guard holds.
+// Patterns are the same as for destructuring, see Destructure.
+func evaluateMatch(context *EvaluationContext, s *Stack, expanded []*Value) *Value {
+  if s.Size < 2 { panic("match expected a value to match") }
+
+  xv := expanded[1].Evaluate(context)
+
+  for _, clause := range expanded[2:] {
+    if clause.T != TypeExpression { panic(fmt.Sprintf("match clause must be a list, got: %v", clause)) }
+
+    parts := clause.AssertExpressionType().Expand()
+
+    var guard *Value
+
+    switch {
+    case len(parts) == 2:
+    case len(parts) == 4 && parts[1].T == TypeID && parts[1].AssertIdType() == ":when": guard = parts[2]
+    default: panic(fmt.Sprintf("match clause must be (pattern body) or (pattern :when guard body), got: %v", clause))
+    }
+
+    clauseContext := context.Extend()
+
+    if destructure(clauseContext, parts[0], xv) != nil { continue }
+    if guard != nil && !guard.Evaluate(clauseContext).Bool() { continue }
+
+    return parts[len(parts) - 1].Evaluate(clauseContext)
+  }
+
+  panic(fmt.Sprintf("non-exhaustive match at %v: no clause matches %v", s.Pos, xv))
+}
@@ -0,0 +1,154 @@
+package yasp
+
+import (
+  "fmt"
+  "os"
+  "path/filepath"
+  "strings"
+)
+
+type Module struct {
+  Name string
+  Path string
+
+  context *EvaluationContext
+  exports map[string]bool
+}
+
+// Modules caches imported files by absolute path, it is shared
+// by all contexts of one evaluation.
+type Modules struct {
+  loaded map[string]*Module
+  loading []string
+}
+
+func newModules() *Modules {
+  return &Modules{loaded: make(map[string]*Module)}
+}
+
+func (m *Module) Export(name string) {
+  m.exports[name] = true
+}
+
+func (m *Module) Get(name string) *Value {
+  if !m.exports[name] { panic(fmt.Sprintf("%v is not exported by module %v", name, m.Name)) }
+
+  value, ok := m.context.Vars[name]
+  if !ok { panic(fmt.Sprintf("module %v exports %v, but does not define it", m.Name, name)) }
+
+  return value
+}
+
+// moduleContext creates root scope of a module sharing evaluation state
+// with the context it was created from.
+func (c *EvaluationContext) moduleContext(m *Module) *EvaluationContext {
+  moduleContext := EmptyEvaluationContext()
+  moduleContext.Strict = m.Path != "" || c.Strict
+  moduleContext.calls = c.calls
+  moduleContext.Debugger = c.Debugger
+  moduleContext.modules = c.modules
+  moduleContext.module = m
+
+  m.context = moduleContext
+
+  return moduleContext
+}
+
+// lookupQualified resolves alias/name identifiers.
+func (c *EvaluationContext) lookupQualified(name string) (*Value, bool) {
+  i := strings.Index(name, "/")
+  if i <= 0 || i == len(name) - 1 { return nil, false }
+
+  module, ok := c.Lookup(name[:i])
+  if !ok || module.T != TypeModule { return nil, false }
+
+  return module.AssertModuleType().Get(name[i + 1:]), true
+}
+
+// evaluateModule evaluates (module name forms...) in a nested scope,
+// names listed in (export ...) forms are accessible as name/x.
+func evaluateModule(context *EvaluationContext, expanded []*Value) *Value {
+  if len(expanded) < 2 { panic("module expected a name") }
+
+  name := expanded[1].AssertIdType()
+  m := &Module{Name: name, exports: make(map[string]bool)}
+
+  moduleContext := context.Extend()
+  moduleContext.module = m
+  m.context = moduleContext
+
+  evaluateForms(moduleContext, expanded[2:])
+
+  module := &Value{T: TypeModule, V: m}
+  context.Define(name, module)
+
+  return module
+}
+
+// evaluateImport evaluates (import 'path' [:as alias]), the path is resolved
+// relative to the file containing the import.
+func evaluateImport(context *EvaluationContext, s *Stack, expanded []*Value) *Value {
+  if len(expanded) != 2 && len(expanded) != 4 { panic("import expected a path and optional :as alias") }
+
+  path := expanded[1].Evaluate(context).AssertStringType()
+  alias := moduleName(path)
+
+  if len(expanded) == 4 {
+    if expanded[2].T != TypeID || expanded[2].AssertIdType() != ":as" {
+      panic(fmt.Sprintf("import expected :as, got: %v", expanded[2]))
+    }
+
+    alias = expanded[3].AssertIdType()
+  }
+
+  module := &Value{T: TypeModule, V: context.modules.load(context, resolveImport(s.Pos.File, path))}
+  context.Define(alias, module)
+
+  return module
+}
+
+func resolveImport(importingFile string, path string) string {
+  if !filepath.IsAbs(path) && importingFile != "" {
+    path = filepath.Join(filepath.Dir(importingFile), path)
+  }
+
+  if filepath.Ext(path) == "" {
+    if _, err := os.Stat(path + ".yasp"); err == nil { path += ".yasp" }
+  }
+
+  absolute, err := filepath.Abs(path)
+  if err != nil { panic(err) }
+
+  return absolute
+}
+
+// moduleName is the file name without extension, the default alias.
+func moduleName(path string) string {
+  return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// load evaluates the file once, modules are named after the file as
+// imports may alias them differently.
+func (modules *Modules) load(context *EvaluationContext, path string) *Module {
+  if m, ok := modules.loaded[path]; ok { return m }
+
+  for i, loading := range modules.loading {
+    if loading == path {
+      cycle := append(append([]string{}, modules.loading[i:]...), path)
+      panic(fmt.Sprintf("import cycle: %v", strings.Join(cycle, " -> ")))
+    }
+  }
+
+  parsing, err := ParseFile(path)
+  if err != nil { panic(err.Error()) }
+
+  modules.loading = append(modules.loading, path)
+  defer func() { modules.loading = modules.loading[:len(modules.loading) - 1] }()
+
+  m := &Module{Name: moduleName(path), Path: path, exports: make(map[string]bool)}
+  parsing.Evaluate(context.moduleContext(m))
+
+  modules.loaded[path] = m
+
+  return m
+}
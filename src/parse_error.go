@@ -0,0 +1,83 @@
+package yasp
+
+import (
+  "bytes"
+  "fmt"
+  "strings"
+)
+
+// ParseError is a syntax error at a position, Excerpt is the source line
+// with a caret under the column.
+type ParseError struct {
+  Pos Position
+  Message string
+  Expected []string
+  Excerpt string
+}
+
+func (e *ParseError) Error() string {
+  var buffer bytes.Buffer
+
+  if e.Pos.IsValid() {
+    buffer.WriteString(e.Pos.String())
+    buffer.WriteString(": ")
+  }
+
+  buffer.WriteString(e.Message)
+
+  if len(e.Expected) > 0 {
+    buffer.WriteString(", expected ")
+    buffer.WriteString(strings.Join(e.Expected, " or "))
+  }
+
+  if e.Excerpt != "" {
+    buffer.WriteString("\n")
+    buffer.WriteString(e.Excerpt)
+  }
+
+  return buffer.String()
+}
+
+// ParseErrors are all errors found in a source, the parser continues
+// after an error to report the following ones.
+type ParseErrors []*ParseError
+
+func (errs ParseErrors) Error() string {
+  messages := make([]string, len(errs))
+
+  for i, e := range errs {
+    messages[i] = e.Error()
+  }
+
+  return strings.Join(messages, "\n")
+}
+
+func (p *Parsing) fail(pos Position, expected []string, format string, args ...interface {}) {
+  panic(&ParseError{Pos: pos, Message: fmt.Sprintf(format, args...), Expected: expected})
+}
+
+// excerpt returns the line of the position with a caret under its column,
+// tabs are kept so the caret lines up.
+func excerpt(source []rune, pos Position) string {
+  if !pos.IsValid() { return "" }
+
+  start := pos.Offset - (pos.Column - 1)
+  if start < 0 || start > len(source) { return "" }
+
+  end := start
+  for end < len(source) && source[end] != '\n' && source[end] != '\r' { end++ }
+
+  line := source[start:end]
+
+  var caret bytes.Buffer
+  for i := 0; i < pos.Column - 1 && i < len(line); i++ {
+    if line[i] == '\t' {
+      caret.WriteRune('\t')
+    } else {
+      caret.WriteRune(' ')
+    }
+  }
+  caret.WriteRune('^')
+
+  return "  " + string(line) + "\n  " + caret.String()
+}
@@ -4,13 +4,40 @@ import (
   "strconv"
   "container/list"
   "bytes"
+  "sort"
+  "strings"
+  "regexp"
+  "unicode/utf8"
 )
 
 type Parsing struct {
+  File string
+  Comments []Comment
+  // Spans maps start offsets of expressions and #_ comments to their end
+  // offsets, only Reader records them
+  Spans map[int]int
+
   stack *Stack
   expressions *list.List
 
   parsingString *bytes.Buffer
+  strings []parsedString
+
+  lineStarts []int
+  ignoredPos Position
+}
+
+// Comment is kept for tools working with the source, like formatter.
+// Text of ignored #_ expressions is their printed form.
+type Comment struct {
+  Pos Position
+  Text string
+}
+
+// parsedString is a string being parsed, strings nest inside ${} parts.
+type parsedString struct {
+  pos Position
+  interpolated bool
 }
 
 func (p *Parsing) Init() {
@@ -18,8 +45,29 @@ func (p *Parsing) Init() {
   p.parsingString = new(bytes.Buffer)
 }
 
-func (p *Parsing) OpenBrace() {
+// Locate converts offset of a rune in the source to a line and column.
+func (p *Parsing) Locate(source string, offset int) Position {
+  if p.lineStarts == nil {
+    p.lineStarts = []int{0}
+
+    for i, r := range []rune(source) {
+      if r == '\n' { p.lineStarts = append(p.lineStarts, i + 1) }
+    }
+  }
+
+  line := sort.Search(len(p.lineStarts), func (i int) bool { return p.lineStarts[i] > offset })
+
+  return Position{File: p.File, Line: line, Column: offset - p.lineStarts[line - 1] + 1, Offset: offset}
+}
+
+// Forms returns top level expressions parsed so far.
+func (p *Parsing) Forms() []*Value {
+  return p.stack.Expand()
+}
+
+func (p *Parsing) OpenBrace(pos Position) {
   newStack := CreateStack(p.stack)
+  newStack.Pos = pos
   p.stack = newStack
 }
 func (p *Parsing) CloseBrace() {
@@ -27,23 +75,149 @@ func (p *Parsing) CloseBrace() {
 
   cur := p.stack
   p.stack = p.stack.Prev
-  p.stack.AddToStack(Value{T: TypeExpression, V: cur})
+  p.stack.AddToStack(Value{T: TypeExpression, V: cur, Pos: cur.Pos})
+}
+
+func (p *Parsing) AddComment(text string, pos Position) {
+  p.Comments = append(p.Comments, Comment{Pos: pos, Text: text})
+}
+
+// StartIgnored parses the ignored expression into a separate stack,
+// EndIgnored drops it.
+func (p *Parsing) StartIgnored(pos Position) {
+  p.stack = CreateStack(p.stack)
+  p.ignoredPos = pos
+}
+func (p *Parsing) EndIgnored() {
+  ignored := p.stack
+  p.stack = p.stack.Prev
+
+  // nothing is left of an expression with syntax errors
+  if ignored.Top != nil { p.AddComment("#_" + ignored.Top.V.String(), p.ignoredPos) }
+}
+
+func (p *Parsing) AddID(id string, pos Position) {
+  for _, r := range id {
+    if r >= utf8.RuneSelf && !isIDLetter(r) {
+      p.fail(pos, nil, "invalid character %q in identifier %v", r, id)
+    }
+  }
+
+  p.stack.AddToStack(Value{T: TypeID, V: id, Pos: pos})
+}
+// AddNumber accepts decimal, 0x hex, 0o octal and 0b binary numbers,
+// digits can be separated by _.
+func (p *Parsing) AddNumber(number string, pos Position) {
+  n, err := parseNumber(number)
+  if (err != nil) { p.fail(pos, nil, "invalid number %v: %v", number, err) }
+  p.stack.AddToStack(Value{T: TypeNumber, V: n, Pos: pos})
+}
+func parseNumber(number string) (uint64, error) {
+  base := 10
+  digits := number
+
+  if len(number) > 2 && number[0] == '0' {
+    switch number[1] {
+    case 'x': base, digits = 16, number[2:]
+    case 'o': base, digits = 8, number[2:]
+    case 'b': base, digits = 2, number[2:]
+    }
+  }
+
+  return strconv.ParseUint(strings.Replace(digits, "_", "", -1), base, 64)
 }
 
-func (p *Parsing) AddID(id string) {
-  p.stack.AddToStack(Value{T: TypeID, V: id})
+// AddRawString takes """text""" literal, a line break right after
+// the opening quotes is dropped.
+func (p *Parsing) AddRawString(literal string, pos Position) {
+  text := literal[3:len(literal) - 3]
+
+  if strings.HasPrefix(text, "\r\n") {
+    text = text[2:]
+  } else if strings.HasPrefix(text, "\n") {
+    text = text[1:]
+  }
+
+  p.stack.AddToStack(Value{T: TypeString, V: text, Pos: pos})
 }
-func (p *Parsing) AddNumber(number string) {
-  n, err := strconv.ParseUint(number, 10, 32)
-  if (err != nil) { panic(err) }
-  p.stack.AddToStack(Value{T: TypeNumber, V: uint64(n)})
+
+var characterNames = map[string]string{
+  "newline": "\n",
+  "space": " ",
+  "tab": "\t",
+  "return": "\r",
 }
 
-func (p *Parsing) StartString() {
+// AddChar takes \c, \newline or \u0041 literal, characters are one
+// character strings.
+func (p *Parsing) AddChar(literal string, pos Position) {
+  name := literal[1:]
+
+  c, ok := characterNames[name]
+  if !ok && len(name) == 5 && name[0] == 'u' {
+    code, err := strconv.ParseUint(name[1:], 16, 32)
+    if err != nil { p.fail(pos, nil, "invalid character %v", literal) }
+
+    c, ok = string(rune(code)), true
+  }
+  if !ok { c = name }
+
+  p.stack.AddToStack(Value{T: TypeString, V: c, Pos: pos})
+}
+
+// AddRegex takes literal #"pattern" where \" stands for a quote.
+func (p *Parsing) AddRegex(literal string, pos Position) {
+  pattern := strings.Replace(literal[2:len(literal) - 1], `\"`, `"`, -1)
+
+  re, err := regexp.Compile(pattern)
+  if err != nil { p.fail(pos, nil, "invalid regex #\"%v\": %v", pattern, err) }
+
+  regex := &Value{T: TypeRegex, V: re, Pos: pos}
+
+  p.stack.AddToStack(*regex)
+}
+
+func (p *Parsing) StartString(pos Position) {
   p.parsingString.Reset()
+  p.strings = append(p.strings, parsedString{pos: pos})
 }
 func (p *Parsing) EndString() {
-  p.stack.AddToStack(Value{T: TypeString, V: p.parsingString.String()})
+  str := p.strings[len(p.strings) - 1]
+  p.strings = p.strings[:len(p.strings) - 1]
+
+  if str.interpolated {
+    p.flushString(str.pos)
+    p.CloseBrace()
+  } else {
+    p.stack.AddToStack(Value{T: TypeString, V: p.parsingString.String(), Pos: str.pos})
+    p.parsingString = new(bytes.Buffer)
+  }
+}
+
+// Interpolation is the head of interpolated strings, (${} 'text' expr ...)
+// concatenates displayed values. It can't be read as an identifier, so
+// bindings can't shadow it.
+const Interpolation = "${}"
+
+// StartInterpolation turns the string into (${} 'text' expr ...).
+func (p *Parsing) StartInterpolation(pos Position) {
+  str := &p.strings[len(p.strings) - 1]
+
+  if !str.interpolated {
+    str.interpolated = true
+
+    p.OpenBrace(str.pos)
+    p.AddID(Interpolation, str.pos)
+  }
+
+  p.flushString(str.pos)
+}
+func (p *Parsing) EndInterpolation() {
+}
+func (p *Parsing) flushString(pos Position) {
+  if p.parsingString.Len() == 0 { return }
+
+  p.stack.AddToStack(Value{T: TypeString, V: p.parsingString.String(), Pos: pos})
   p.parsingString = new(bytes.Buffer)
 }
 
@@ -0,0 +1,23 @@
+package yasp
+
+import ( "fmt" )
+
+type Position struct {
+  File string `json:"file"`
+  Line int `json:"line"`
+  Column int `json:"column"`
+  // in runes from the beginning of the source
+  Offset int `json:"offset"`
+}
+
+func (p Position) IsValid() bool {
+  return p.Line > 0
+}
+
+func (p Position) String() string {
+  if !p.IsValid() { return "<unknown>" }
+
+  if p.File == "" { return fmt.Sprintf("%v:%v", p.Line, p.Column) }
+
+  return fmt.Sprintf("%v:%v:%v", p.File, p.Line, p.Column)
+}
@@ -0,0 +1,27 @@
+package yasp
+
+import (
+  _ "embed"
+  "sync"
+)
+
+//go:embed prelude.yasp
+var preludeSource string
+
+var prelude struct {
+  once sync.Once
+  parsing *Parsing
+}
+
+// loadPrelude defines helpers from prelude.yasp in the context,
+// the source is parsed once per process.
+func loadPrelude(context *EvaluationContext) {
+  prelude.once.Do(func () {
+    parsing, err := Parse(preludeSource, "prelude.yasp")
+    if err != nil { panic(err) }
+
+    prelude.parsing = parsing
+  })
+
+  prelude.parsing.Evaluate(context)
+}
@@ -0,0 +1,177 @@
+package yasp
+
+import (
+  "fmt"
+  "strings"
+)
+
+// Print returns YASP source for the value, unlike Display it is meant to be
+// read back. Code (identifiers, numbers, strings, regexes and expressions)
+// reads back as the same value, other values print as expressions that
+// evaluate to an equal value, like (list 1 'a').
+func (v *Value) Print() string {
+  switch v.T {
+  case TypeID: return v.AssertIdType()
+  case TypeNumber: return v.String()
+  case TypeString: return printString(v.AssertStringType())
+  case TypeRegex: {
+    pattern := v.AssertRegexType().String()
+
+    // \" in a literal is a quote, so such patterns need a string
+    if strings.Contains(pattern, `\"`) { return "(regex " + printString(pattern) + ")" }
+
+    return `#"` + strings.Replace(pattern, `"`, `\"`, -1) + `"`
+  }
+  case TypeExpression: {
+    elems := v.AssertExpressionType().Expand()
+    if len(elems) > 0 && elems[0].T == TypeID && elems[0].AssertIdType() == Interpolation { return printInterpolation(elems[1:]) }
+
+    return printForms("", elems, false)
+  }
+  case TypeNil: return "()"
+  case TypeList: return printForms("list", v.AssertListType(), true)
+  case TypeAtom: return printForms("atom", []*Value{v.AssertAtomType().Value}, true)
+  case TypeStruct: {
+    sv := v.AssertStructType()
+    return printForms(sv.Type.Name, sv.Values, true)
+  }
+  case TypeEnum: {
+    ev := v.AssertEnumType()
+    return "(" + ev.Type.Name + " " + ev.Variant() + ")"
+  }
+  case TypeStructType: {
+    st := v.V.(*StructType)
+    fields := []string{"defstruct", st.Name}
+
+    for _, field := range st.Fields {
+      if field.Type == "" {
+        fields = append(fields, field.Name)
+      } else {
+        fields = append(fields, "(" + field.Name + " " + field.Type + ")")
+      }
+    }
+
+    return "(" + strings.Join(fields, " ") + ")"
+  }
+  case TypeEnumType: {
+    et := v.V.(*EnumType)
+    return "(" + strings.Join(append([]string{"defenum", et.Name}, et.Variants...), " ") + ")"
+  }
+  case TypeError: {
+    ev := v.AssertErrorType()
+    if ev.Data == nil { return "(error " + printString(ev.Message) + ")" }
+
+    return "(error " + printString(ev.Message) + " " + Quote(ev.Data).Print() + ")"
+  }
+  case TypeModule: {
+    m := v.AssertModuleType()
+    if m.Path == "" { return "(module " + m.Name + ")" }
+
+    return "(import " + printString(m.Path) + " :as " + m.Name + ")"
+  }
+  case TypeFunction: {
+    fv := v.AssertFunctionType()
+    return "(fn " + fv.Parameters() + " " + fv.body.Print() + ")"
+  }
+  default: return fmt.Sprintf("(error 'unknown type %v')", v.T)
+  }
+}
+
+// printForms prints (head x ...), data values are quoted when they would
+// evaluate to something else.
+func printForms(head string, values []*Value, data bool) string {
+  forms := []string{}
+  if head != "" { forms = append(forms, head) }
+
+  for _, x := range values {
+    if data { x = Quote(x) }
+    forms = append(forms, x.Print())
+  }
+
+  return "(" + strings.Join(forms, " ") + ")"
+}
+
+// Parameters returns the parameter list as written in fn.
+func (fv *ValueFunction) Parameters() string {
+  params := []string{}
+
+  for _, x := range fv.args {
+    params = append(params, x.Print())
+  }
+
+  printDefaults := func (section string, parameters []FunctionParameter) {
+    if len(parameters) == 0 { return }
+
+    params = append(params, section)
+
+    for _, p := range parameters {
+      if p.Default == nil {
+        params = append(params, p.Pattern.Print())
+      } else {
+        params = append(params, "(" + p.Pattern.Print() + " " + p.Default.Print() + ")")
+      }
+    }
+  }
+
+  printDefaults("&optional", fv.optional)
+
+  if fv.rest != nil { params = append(params, "&", fv.rest.Print()) }
+
+  printDefaults("&key", fv.keys)
+
+  return "(" + strings.Join(params, " ") + ")"
+}
+
+var printedEscapes = map[rune]string{
+  '\\': `\\`, '\'': `\'`, '\n': `\n`, '\t': `\t`, '\r': `\r`,
+}
+
+// printString quotes the string with single quotes, which have no
+// interpolation, other control characters are octal escapes.
+func printString(s string) string {
+  var buffer strings.Builder
+
+  buffer.WriteRune('\'')
+
+  for _, c := range s {
+    if escaped, ok := printedEscapes[c]; ok {
+      buffer.WriteString(escaped)
+    } else if c < 0x20 || c == 0x7f {
+      fmt.Fprintf(&buffer, `\%03o`, c)
+    } else {
+      buffer.WriteRune(c)
+    }
+  }
+
+  buffer.WriteRune('\'')
+
+  return buffer.String()
+}
+
+// printInterpolation prints (${} 'text' expr ...) as "text${expr}...".
+func printInterpolation(parts []*Value) string {
+  var buffer strings.Builder
+
+  buffer.WriteRune('"')
+
+  for _, x := range parts {
+    if x.T != TypeString {
+      buffer.WriteString("${" + x.Print() + "}")
+      continue
+    }
+
+    for _, c := range x.AssertStringType() {
+      switch {
+      case c == '"' || c == '$': buffer.WriteString(`\` + string(c))
+      case c == '\'': buffer.WriteRune(c)
+      case printedEscapes[c] != "": buffer.WriteString(printedEscapes[c])
+      case c < 0x20 || c == 0x7f: fmt.Fprintf(&buffer, `\%03o`, c)
+      default: buffer.WriteRune(c)
+      }
+    }
+  }
+
+  buffer.WriteRune('"')
+
+  return buffer.String()
+}
@@ -0,0 +1,544 @@
+package yasp
+
+import (
+  "bufio"
+  "fmt"
+  "io"
+  "sort"
+  "strconv"
+  "strings"
+  "unicode"
+  "unicode/utf8"
+)
+
+// Reader is a recursive descent parser for the grammar in syntax.peg,
+// it reads the source from an io.Reader and builds values with the same
+// Parsing actions as the generated parser. It continues after syntax
+// errors to report all of them.
+type Reader struct {
+  Parsing
+
+  in *bufio.Reader
+  pos Position
+  err error
+
+  errs ParseErrors
+  // errors before the current form
+  formErrs int
+
+  // consumed source, for excerpts, base is the offset it starts at
+  source strings.Builder
+  base int
+  // bytes of the input consumed, invalid UTF-8 is read as U+FFFD
+  consumed int
+}
+
+// readError wraps errors of the underlying io.Reader.
+type readError struct {
+  err error
+}
+
+func NewReader(in io.Reader, file string) *Reader {
+  r := &Reader{in: bufio.NewReader(in)}
+  r.Parsing.Init()
+  r.File = file
+  r.Spans = make(map[int]int)
+  r.pos = Position{File: file, Line: 1, Column: 1}
+
+  return r
+}
+
+// ReadForm reads the next top level form, io.EOF after the last one.
+// A form with syntax errors returns the first one as *ParseError, the
+// next call continues after the form.
+func (r *Reader) ReadForm() (form *Value, err error) {
+  if r.err != nil { return nil, r.err }
+
+  defer func() {
+    if rec := recover(); rec != nil {
+      e, ok := rec.(readError)
+      if !ok { panic(rec) }
+
+      r.err = e.err
+      form, err = nil, r.err
+    }
+  }()
+
+  r.formErrs = len(r.errs)
+
+  r.ws()
+  if r.atEnd() && len(r.errs) == r.formErrs { return nil, io.EOF }
+
+  if !r.atEnd() {
+    r.expr()
+    if !r.separated() { r.report(r.pos, []string{"whitespace"}, "unexpected %q", r.peek()) }
+  }
+
+  if len(r.errs) > r.formErrs {
+    e := r.errs[r.formErrs]
+    e.Excerpt = r.excerpt(e.Pos)
+
+    return nil, e
+  }
+
+  return &r.stack.Top.V, nil
+}
+
+// Read parses the rest of the input, ParseErrors lists all syntax errors.
+func (r *Reader) Read() (*Parsing, error) {
+  for {
+    _, err := r.ReadForm()
+    if err == io.EOF { break }
+
+    if _, ok := err.(*ParseError); err != nil && !ok { return nil, err }
+  }
+
+  if len(r.errs) == 0 { return &r.Parsing, nil }
+
+  // lines are complete now
+  for _, e := range r.errs { e.Excerpt = r.excerpt(e.Pos) }
+
+  // unclosed braces are reported after the errors inside them
+  sort.SliceStable(r.errs, func (i, j int) bool { return r.errs[i].Pos.Offset < r.errs[j].Pos.Offset })
+
+  return nil, r.errs
+}
+
+// report records a syntax error, the caller resumes reading after it.
+func (r *Reader) report(pos Position, expected []string, format string, args ...interface {}) {
+  r.errs = append(r.errs, &ParseError{Pos: pos, Message: fmt.Sprintf(format, args...), Expected: expected})
+}
+
+// literal runs the Parsing action adding a literal, actions reject
+// malformed literals by panicking.
+func (r *Reader) literal(action func ()) {
+  defer func() {
+    if rec := recover(); rec != nil {
+      e, ok := rec.(*ParseError)
+      if !ok { panic(rec) }
+
+      r.errs = append(r.errs, e)
+    }
+  }()
+
+  action()
+}
+
+// excerpt of the consumed source and the input buffered after it.
+func (r *Reader) excerpt(pos Position) string {
+  buffered, _ := r.in.Peek(r.in.Buffered())
+  pos.Offset -= r.base
+
+  return excerpt([]rune(r.source.String() + string(buffered)), pos)
+}
+
+// discard drops the forms read so far with their source and spans, the
+// current line is kept for excerpts. A long running reader doesn't grow.
+func (r *Reader) discard() {
+  source := r.source.String()
+
+  line := len(source)
+  for i := 1; i < r.pos.Column; i++ {
+    _, size := utf8.DecodeLastRuneInString(source[:line])
+    line -= size
+  }
+
+  r.source.Reset()
+  r.source.WriteString(source[line:])
+  r.base = r.pos.Offset - (r.pos.Column - 1)
+
+  r.Spans = make(map[int]int)
+  r.stack = CreateStack(nil)
+  r.errs, r.formErrs = nil, 0
+}
+
+func (r *Reader) peekBytes(n int) []byte {
+  b, err := r.in.Peek(n)
+  if err != nil && err != io.EOF && err != bufio.ErrBufferFull { panic(readError{err}) }
+
+  return b
+}
+func (r *Reader) atEnd() bool {
+  return len(r.peekBytes(1)) == 0
+}
+// peek returns the next rune, 0 at the end of input. Like hasPrefix it
+// doesn't look further than needed, so a paused input isn't waited for.
+func (r *Reader) peek() rune {
+  b := r.peekBytes(1)
+  if len(b) == 0 { return 0 }
+
+  for n := 2; !utf8.FullRune(b) && n <= utf8.UTFMax; n++ { b = r.peekBytes(n) }
+
+  c, _ := utf8.DecodeRune(b)
+  return c
+}
+func (r *Reader) hasPrefix(prefix string) bool {
+  for i := 0; i < len(prefix); i++ {
+    if !r.peekIn(i, prefix[i:i + 1]) { return false }
+  }
+
+  return true
+}
+// peekIn checks that the byte at i is one of chars.
+func (r *Reader) peekIn(i int, chars string) bool {
+  b := r.peekBytes(i + 1)
+  return len(b) > i && strings.IndexByte(chars, b[i]) >= 0
+}
+
+func (r *Reader) next() rune {
+  c, size, err := r.in.ReadRune()
+  if err != nil { panic(readError{err}) }
+
+  r.source.WriteRune(c)
+  r.consumed += size
+
+  r.pos.Offset++
+  if c == '\n' {
+    r.pos.Line++
+    r.pos.Column = 1
+  } else {
+    r.pos.Column++
+  }
+
+  return c
+}
+// skip consumes n runes and returns them.
+func (r *Reader) skip(n int) string {
+  var buffer strings.Builder
+
+  for i := 0; i < n; i++ {
+    buffer.WriteRune(r.next())
+  }
+
+  return buffer.String()
+}
+// takeWhile consumes runes while f holds.
+func (r *Reader) takeWhile(f func (rune) bool) string {
+  var buffer strings.Builder
+
+  for !r.atEnd() && f(r.peek()) {
+    buffer.WriteRune(r.next())
+  }
+
+  return buffer.String()
+}
+
+// separated reports whether an expression can end here.
+func (r *Reader) separated() bool {
+  return r.atEnd() || strings.ContainsRune(" \t\r\n)", r.peek()) ||
+    r.hasPrefix("//") || r.hasPrefix("/*") || r.hasPrefix("#_")
+}
+
+// ws skips whitespace, comments and #_ expressions, reports whether
+// there were any.
+func (r *Reader) ws() bool {
+  start := r.pos.Offset
+
+  for !r.atEnd() {
+    pos := r.pos
+
+    switch {
+    case strings.ContainsRune(" \t\r\n", r.peek()): r.next()
+    case r.hasPrefix("//"): {
+      r.AddComment(r.takeWhile(func (c rune) bool { return c != '\n' }), pos)
+    }
+    case r.hasPrefix("/*"): r.AddComment(r.blockComment(), pos)
+    case r.hasPrefix("#_"): {
+      r.skip(2)
+      r.ws()
+
+      if r.atEnd() || r.peek() == ')' {
+        r.report(pos, []string{"expression"}, "nothing to ignore after #_")
+        continue
+      }
+
+      r.StartIgnored(pos)
+      r.expr()
+      r.EndIgnored()
+      r.Spans[pos.Offset] = r.pos.Offset
+    }
+    default: return r.pos.Offset > start
+    }
+  }
+
+  return r.pos.Offset > start
+}
+
+func (r *Reader) blockComment() string {
+  pos := r.pos
+  var buffer strings.Builder
+
+  depth := 0
+  for {
+    switch {
+    case r.atEnd(): {
+      r.report(pos, []string{"'*/'"}, "unterminated comment")
+      return buffer.String()
+    }
+    case r.hasPrefix("/*"): {
+      depth++
+      buffer.WriteString(r.skip(2))
+    }
+    case r.hasPrefix("*/"): {
+      depth--
+      buffer.WriteString(r.skip(2))
+
+      if depth == 0 { return buffer.String() }
+    }
+    default: buffer.WriteRune(r.next())
+    }
+  }
+}
+
+func (r *Reader) expr() {
+  c := r.peek()
+
+  start := r.pos.Offset
+  defer func() { r.Spans[start] = r.pos.Offset }()
+
+  switch {
+  case r.atEnd(): r.report(r.pos, []string{"expression"}, "unexpected end of input")
+  case c == '(': r.list()
+  case c == ')': {
+    r.report(r.pos, nil, "unexpected ')' without matching '('")
+    r.next()
+  }
+  case r.hasPrefix(`#"`): r.regex()
+  case r.hasPrefix(`"""`): r.rawString()
+  case c == '"' || c == '\'': r.string(c)
+  case c == '\\': r.char()
+  case c >= '0' && c <= '9': r.number()
+  case isIDStart(c): r.id()
+  default: {
+    r.report(r.pos, []string{"expression"}, "unexpected %q", c)
+    r.next()
+  }
+  }
+}
+
+func (r *Reader) list() {
+  open := r.pos
+  r.next()
+  r.OpenBrace(open)
+  r.ws()
+
+  for {
+    if r.atEnd() {
+      r.report(open, []string{"')'"}, "unclosed '('")
+      r.CloseBrace()
+      return
+    }
+
+    if r.peek() == ')' {
+      r.next()
+      r.CloseBrace()
+      return
+    }
+
+    r.expr()
+
+    if !r.ws() && !r.atEnd() && r.peek() != ')' {
+      r.report(r.pos, []string{"whitespace", "')'"}, "unexpected %q", r.peek())
+    }
+  }
+}
+
+func (r *Reader) id() {
+  pos := r.pos
+  id := r.takeWhile(isIDChar)
+  r.literal(func () { r.AddID(id, pos) })
+}
+
+// number follows NUMBER, a prefix without digits is a decimal 0.
+func (r *Reader) number() {
+  pos := r.pos
+  digits := "0123456789_"
+
+  for _, prefix := range []struct { prefix string; digits string } {
+    {"0x", "0123456789abcdefABCDEF_"},
+    {"0o", "01234567_"},
+    {"0b", "01_"},
+  } {
+    if r.hasPrefix(prefix.prefix) && r.peekIn(2, prefix.digits) {
+      number := r.skip(2) + r.takeWhile(func (c rune) bool { return strings.ContainsRune(prefix.digits, c) })
+      r.literal(func () { r.AddNumber(number, pos) })
+      return
+    }
+  }
+
+  number := r.takeWhile(func (c rune) bool { return strings.ContainsRune(digits, c) })
+  r.literal(func () { r.AddNumber(number, pos) })
+}
+
+func (r *Reader) regex() {
+  pos := r.pos
+  var buffer strings.Builder
+
+  buffer.WriteString(r.skip(2))
+
+  for {
+    switch {
+    case r.atEnd(): {
+      r.report(pos, []string{`'"'`}, "unterminated regex")
+      return
+    }
+    case r.hasPrefix(`\"`): buffer.WriteString(r.skip(2))
+    case r.peek() == '"': {
+      buffer.WriteRune(r.next())
+      r.literal(func () { r.AddRegex(buffer.String(), pos) })
+      return
+    }
+    default: buffer.WriteRune(r.next())
+    }
+  }
+}
+
+func (r *Reader) rawString() {
+  pos := r.pos
+  var buffer strings.Builder
+
+  buffer.WriteString(r.skip(3))
+
+  for !r.hasPrefix(`"""`) {
+    if r.atEnd() {
+      r.report(pos, []string{`'"""'`}, "unterminated raw string")
+      return
+    }
+
+    buffer.WriteRune(r.next())
+  }
+
+  buffer.WriteString(r.skip(3))
+  r.AddRawString(buffer.String(), pos)
+}
+
+func (r *Reader) string(quote rune) {
+  pos := r.pos
+  r.next()
+  r.StartString(pos)
+
+  for {
+    switch {
+    case r.atEnd(): {
+      r.report(pos, []string{fmt.Sprintf("%q", string(quote))}, "unterminated string")
+      r.EndString()
+      return
+    }
+    case r.peek() == quote: {
+      r.next()
+      r.EndString()
+      return
+    }
+    case r.peek() == '\\': r.escape()
+    case quote == '"' && r.hasPrefix("${"): r.interpolation()
+    default: {
+      r.AddCharacter(r.takeWhile(func (c rune) bool {
+        return c != quote && c != '\\' && !(quote == '"' && c == '$' && r.hasPrefix("${"))
+      }))
+    }
+    }
+  }
+}
+
+var escapes = map[rune]string{
+  'a': "\a", 'b': "\b", 'e': "\x1B", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t", 'v': "\v",
+  '\'': "'", '"': "\"", '[': "[", ']': "]", '$': "$", '-': "-", '\\': "\\",
+}
+
+// escape follows ESCAPE.
+func (r *Reader) escape() {
+  pos := r.pos
+  r.next()
+
+  if s, ok := escapes[r.peek()]; ok && !r.atEnd() {
+    r.next()
+    r.AddCharacter(s)
+    return
+  }
+
+  isOctal := func (c rune) bool { return c >= '0' && c <= '7' }
+
+  switch {
+  case r.hasPrefix("0x") && r.peekIn(2, "0123456789abcdefABCDEF"): {
+    r.skip(2)
+    hexa, _ := strconv.ParseInt(r.takeWhile(func (c rune) bool { return strings.ContainsRune("0123456789abcdefABCDEF", c) }), 16, 32)
+    r.AddCharacter(string(rune(hexa)))
+  }
+  case r.peekIn(0, "0123") && r.peekIn(1, "01234567") && r.peekIn(2, "01234567"): {
+    octal, _ := strconv.ParseInt(r.skip(3), 8, 8)
+    r.AddCharacter(string(rune(octal)))
+  }
+  case isOctal(r.peek()): {
+    text := r.skip(1)
+    if r.peekIn(0, "01234567") { text += r.skip(1) }
+
+    octal, _ := strconv.ParseInt(text, 8, 8)
+    r.AddCharacter(string(rune(octal)))
+  }
+  default: r.report(pos, nil, "invalid escape sequence \\%c", r.peek())
+  }
+}
+
+func (r *Reader) interpolation() {
+  pos := r.pos
+  r.skip(2)
+  r.StartInterpolation(pos)
+  r.ws()
+
+  if r.atEnd() || r.peek() == '}' || r.peek() == '"' {
+    r.report(pos, []string{"expression"}, "empty interpolation")
+  } else {
+    r.expr()
+    r.ws()
+  }
+
+  if r.peek() != '}' {
+    r.report(pos, []string{"'}'"}, "unclosed '${'")
+
+    // resume at the end of the interpolation or of the string
+    r.takeWhile(func (c rune) bool { return c != '}' && c != '"' })
+    if r.atEnd() || r.peek() != '}' { return }
+  }
+
+  r.next()
+  r.EndInterpolation()
+}
+
+// char follows CHAR, names are tried before a single character.
+func (r *Reader) char() {
+  pos := r.pos
+  literal := r.skip(1)
+
+  if r.atEnd() {
+    r.report(pos, []string{"character"}, "unexpected end of input")
+    return
+  }
+
+  for _, name := range []string{"newline", "space", "tab", "return"} {
+    if r.hasPrefix(name) {
+      literal += r.skip(len(name))
+      r.literal(func () { r.AddChar(literal, pos) })
+      return
+    }
+  }
+
+  hex := "0123456789abcdefABCDEF"
+  if r.peek() == 'u' && r.peekIn(1, hex) && r.peekIn(2, hex) && r.peekIn(3, hex) && r.peekIn(4, hex) {
+    literal += r.skip(5)
+    r.literal(func () { r.AddChar(literal, pos) })
+    return
+  }
+
+  literal += r.skip(1)
+  r.literal(func () { r.AddChar(literal, pos) })
+}
+
+func isIDStart(r rune) bool {
+  return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || strings.ContainsRune("_-+*/!@#$%^&<>=?:.", r) || r >= 0x80
+}
+func isIDChar(r rune) bool {
+  return isIDStart(r) || (r >= '0' && r <= '9') || r == '\'' || r == '"'
+}
+func isIDLetter(r rune) bool {
+  return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
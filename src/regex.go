@@ -0,0 +1,50 @@
+package yasp
+
+import (
+  "fmt"
+  "regexp"
+  "strings"
+)
+
+func compileRegex(pattern string) *Value {
+  re, err := regexp.Compile(pattern)
+  if err != nil { panic(fmt.Sprintf("invalid regex #\"%v\": %v", pattern, err)) }
+
+  return &Value{T: TypeRegex, V: re}
+}
+
+// asRegex accepts both regex values and strings with a pattern.
+func (v *Value) asRegex() *regexp.Regexp {
+  if v.T == TypeString { return compileRegex(v.AssertStringType()).AssertRegexType() }
+
+  return v.AssertRegexType()
+}
+
+// groupsList converts match with capture groups to a list
+// (whole match, group 1, ...), () when there is no match.
+func groupsList(groups []string) *Value {
+  if groups == nil { return &Value{T: TypeNil} }
+
+  return stringList(groups)
+}
+
+// reMatch matches the whole string.
+func reMatch(re *regexp.Regexp, s string) *Value {
+  anchored := regexp.MustCompile(`^(?:` + re.String() + `)$`)
+
+  return groupsList(anchored.FindStringSubmatch(s))
+}
+
+func reFindAll(re *regexp.Regexp, s string) *Value {
+  result := []*Value{}
+
+  for _, groups := range re.FindAllStringSubmatch(s, -1) {
+    result = append(result, stringList(groups))
+  }
+
+  return &Value{T: TypeList, V: result}
+}
+
+func regexString(re *regexp.Regexp) string {
+  return `#"` + strings.Replace(re.String(), `"`, `\"`, -1) + `"`
+}
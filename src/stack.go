@@ -8,6 +8,7 @@ type StackNode struct {
 }
 type Stack struct {
   Prev *Stack
+  Pos Position
 
   Top *StackNode
   Size uint32
@@ -0,0 +1,82 @@
+package yasp
+
+import (
+  "bytes"
+  "fmt"
+  "unicode/utf8"
+)
+
+// Display converts value to a human readable text, unlike String
+// strings are shown without quotes.
+func (v *Value) Display() string {
+  switch v.T {
+  case TypeString: return v.AssertStringType()
+  case TypeError: return v.AssertErrorType().Message
+  case TypeList: {
+    var buffer bytes.Buffer
+
+    buffer.WriteString("[")
+
+    for i, x := range v.AssertListType() {
+      if i > 0 { buffer.WriteString(", ") }
+      buffer.WriteString(x.Display())
+    }
+
+    buffer.WriteString("]")
+
+    return buffer.String()
+  }
+  default: return v.String()
+  }
+}
+
+func stringValues(lst []*Value) []string {
+  strs := make([]string, len(lst))
+
+  for i, x := range lst {
+    strs[i] = x.AssertStringType()
+  }
+
+  return strs
+}
+
+func stringList(strs []string) *Value {
+  lst := make([]*Value, len(strs))
+
+  for i, s := range strs {
+    lst[i] = &Value{T: TypeString, V: s}
+  }
+
+  return &Value{T: TypeList, V: lst}
+}
+
+// runeIndex converts byte offset returned by strings package to a character index.
+func runeIndex(s string, byteIndex int) *Value {
+  if byteIndex < 0 { return &Value{T: TypeNil} }
+
+  return &Value{T: TypeNumber, V: uint64(utf8.RuneCountInString(s[:byteIndex]))}
+}
+
+func substring(text *Text, start uint64, end uint64) *Text {
+  if start > end || end > uint64(text.Len()) {
+    panic(fmt.Sprintf("substring [%v, %v) is out of range of string of length %v", start, end, text.Len()))
+  }
+
+  return text.Slice(int(start), int(end))
+}
+
+// formatString implements printf-style format, numbers and strings are
+// passed to fmt as is, other values as their display form.
+func formatString(format string, args []*Value) string {
+  fmtArgs := make([]interface{}, len(args))
+
+  for i, x := range args {
+    switch x.T {
+    case TypeNumber: fmtArgs[i] = x.AssertNumberType()
+    case TypeString: fmtArgs[i] = x.AssertStringType()
+    default: fmtArgs[i] = x.Display()
+    }
+  }
+
+  return fmt.Sprintf(format, fmtArgs...)
+}
@@ -0,0 +1,104 @@
+package yasp
+
+import ( "fmt" )
+
+// CreateStructType parses fields of the form `name` or `(name Type)`.
+func CreateStructType(name string, fields []*Value) *StructType {
+  st := &StructType{Name: name}
+
+  for _, x := range fields {
+    switch x.T {
+    case TypeID: st.Fields = append(st.Fields, StructField{Name: x.AssertIdType()})
+    case TypeExpression: {
+      pair := x.AssertExpressionType()
+      if pair.Size != 2 { panic(fmt.Sprintf("struct field must be (name Type), got: %v", x)) }
+
+      expanded := pair.Expand()
+      st.Fields = append(st.Fields, StructField{Name: expanded[0].AssertIdType(), Type: expanded[1].AssertIdType()})
+    }
+    default: panic(fmt.Sprintf("Unknown type for struct field: %v", x.T))
+    }
+  }
+
+  return st
+}
+
+func (st *StructType) FieldIndex(name string) int {
+  for i, field := range st.Fields {
+    if field.Name == name { return i }
+  }
+
+  return -1
+}
+
+// Construct accepts either positional arguments (Point 1 2)
+// or field names followed by values (Point x 1 y 2).
+func (st *StructType) Construct(context *EvaluationContext, args []*Value) *Value {
+  fieldsCount := len(st.Fields)
+  values := make([]*Value, fieldsCount)
+
+  if st.isNamedConstruction(args) {
+    for i := 0; i < len(args); i += 2 {
+      values[st.FieldIndex(args[i].AssertIdType())] = args[i + 1].Evaluate(context)
+    }
+  } else {
+    if len(args) != fieldsCount {
+      panic(fmt.Sprintf("%v expected %v fields, got %v", st.Name, fieldsCount, len(args)))
+    }
+
+    for i, x := range args {
+      values[i] = x.Evaluate(context)
+    }
+  }
+
+  for i, field := range st.Fields {
+    if !fieldTypeMatches(context, field.Type, values[i]) {
+      panic(fmt.Sprintf("%v field %v expected to be %v, got: %v", st.Name, field.Name, field.Type, values[i]))
+    }
+  }
+
+  return &Value{T: TypeStruct, V: &StructValue{Type: st, Values: values}}
+}
+
+func (st *StructType) isNamedConstruction(args []*Value) bool {
+  if len(st.Fields) == 0 || len(args) != 2 * len(st.Fields) { return false }
+
+  seen := make(map[string]bool)
+
+  for i := 0; i < len(args); i += 2 {
+    if args[i].T != TypeID { return false }
+
+    name, _ := args[i].V.(string)
+    if st.FieldIndex(name) < 0 || seen[name] { return false }
+
+    seen[name] = true
+  }
+
+  return true
+}
+
+func fieldTypeMatches(context *EvaluationContext, typeName string, v *Value) bool {
+  switch typeName {
+  case "": return true
+  case "Number": return v.T == TypeNumber
+  case "String": return v.T == TypeString
+  case "List": return v.T == TypeList
+  case "Function": return v.T == TypeFunction
+  }
+
+  definition, ok := context.Lookup(typeName)
+  if !ok { return true }
+
+  switch definition.T {
+  case TypeStructType: return v.T == TypeStruct && v.AssertStructType().Type == definition.AssertStructTypeType()
+  case TypeEnumType: return v.T == TypeEnum && v.AssertEnumType().Type == definition.AssertEnumTypeType()
+  default: return true
+  }
+}
+
+func (sv *StructValue) Get(name string) *Value {
+  i := sv.Type.FieldIndex(name)
+  if i < 0 { panic(fmt.Sprintf("%v has no field %v", sv.Type.Name, name)) }
+
+  return sv.Values[i]
+}
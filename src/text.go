@@ -0,0 +1,38 @@
+package yasp
+
+// Text is a string value kept as runes, so taking characters by index
+// and slicing (head, tail, take, skip, len) don't walk the whole string.
+// Slices share the underlying runes with the original text.
+type Text struct {
+  runes []rune
+  str *string
+}
+
+func NewText(s string) *Text {
+  return &Text{runes: []rune(s), str: &s}
+}
+
+func (t *Text) String() string {
+  if t.str == nil {
+    s := string(t.runes)
+    t.str = &s
+  }
+
+  return *t.str
+}
+
+func (t *Text) Len() int {
+  return len(t.runes)
+}
+
+func (t *Text) At(i int) rune {
+  return t.runes[i]
+}
+
+func (t *Text) Slice(start int, end int) *Text {
+  return &Text{runes: t.runes[start:end]}
+}
+
+func textValue(t *Text) *Value {
+  return &Value{T: TypeString, V: t}
+}
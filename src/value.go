@@ -14,19 +14,63 @@ const (
   TypeFunction
   TypeNil
   TypeList
+  TypeAtom
+  TypeStructType
+  TypeStruct
+  TypeEnumType
+  TypeEnum
+  TypeError
+  TypeModule
+  TypeRegex
 )
 
 type Value struct {
   T Type
   V interface {}
+  Pos Position
 }
 
 type ValueFunction struct {
+  name string
   boundContext *EvaluationContext
-  argsNames []string
+  args []*Value
+  optional []FunctionParameter
+  rest *Value
+  keys []FunctionParameter
   body *Value
 }
 
+type FunctionParameter struct {
+  Pattern *Value
+  Default *Value
+}
+
+type Atom struct {
+  Value *Value
+}
+
+type StructType struct {
+  Name string
+  Fields []StructField
+}
+type StructField struct {
+  Name string
+  Type string
+}
+type StructValue struct {
+  Type *StructType
+  Values []*Value
+}
+
+type EnumType struct {
+  Name string
+  Variants []string
+}
+type EnumValue struct {
+  Type *EnumType
+  Index int
+}
+
 func (v *Value) String() string {
   switch v.T {
   case TypeID: {
@@ -34,8 +78,7 @@ func (v *Value) String() string {
     return s
   }
   case TypeString: {
-    s, _ := v.V.(string)
-    return "'" + s + "'"
+    return "'" + v.AssertStringType() + "'"
   }
   case TypeNumber: {
     n, _ := v.V.(uint64)
@@ -72,7 +115,49 @@ func (v *Value) String() string {
   case TypeNil: {
     return "()"
   }
-  default: panic(fmt.Sprintf("unknown type: %v", v.T))
+  case TypeAtom: {
+    a, _ := v.V.(*Atom)
+    return "<atom " + a.Value.String() + ">"
+  }
+  case TypeStructType: {
+    st, _ := v.V.(*StructType)
+    return "<struct " + st.Name + ">"
+  }
+  case TypeStruct: {
+    sv, _ := v.V.(*StructValue)
+
+    var buffer bytes.Buffer
+
+    buffer.WriteString("(")
+    buffer.WriteString(sv.Type.Name)
+
+    for _, x := range sv.Values {
+      buffer.WriteString(" ")
+      buffer.WriteString(x.String())
+    }
+
+    buffer.WriteString(")")
+
+    return buffer.String()
+  }
+  case TypeEnumType: {
+    et, _ := v.V.(*EnumType)
+    return "<enum " + et.Name + ">"
+  }
+  case TypeEnum: {
+    ev, _ := v.V.(*EnumValue)
+    return "(" + ev.Type.Name + " " + ev.Variant() + ")"
+  }
+  case TypeError: {
+    ev, _ := v.V.(*ErrorValue)
+    return "<error " + ev.Message + ">"
+  }
+  case TypeModule: {
+    m, _ := v.V.(*Module)
+    return "<module " + m.Name + ">"
+  }
+  case TypeRegex: return regexString(v.AssertRegexType())
+  default: return fmt.Sprintf("<unknown type %v>", v.T)
   }
 }
 
@@ -80,16 +165,47 @@ func (a *Value) Equals(b *Value) bool {
   if a.T != b.T { return false }
 
   switch a.T {
-  case TypeString: {
+  case TypeString: return a.AssertStringType() == b.AssertStringType()
+  case TypeRegex: return a.AssertRegexType().String() == b.AssertRegexType().String()
+  case TypeID: {
     as, _ := a.V.(string)
     bs, _ := b.V.(string)
 
     return as == bs
   }
+  case TypeNumber: {
+    an, _ := a.V.(uint64)
+    bn, _ := b.V.(uint64)
+
+    return an == bn
+  }
+  case TypeNil: return true
+  case TypeList: return valuesEqual(a.AssertListType(), b.AssertListType())
+  case TypeExpression: return valuesEqual(a.AssertExpressionType().Expand(), b.AssertExpressionType().Expand())
+  case TypeStruct: {
+    as, bs := a.AssertStructType(), b.AssertStructType()
+
+    return as.Type == bs.Type && valuesEqual(as.Values, bs.Values)
+  }
+  case TypeEnum: {
+    ae, be := a.AssertEnumType(), b.AssertEnumType()
+
+    return ae.Type == be.Type && ae.Index == be.Index
+  }
   default: panic(fmt.Sprintf("Unsupported type for comparison: %v", a.T))
   }
 }
 
+func valuesEqual(a []*Value, b []*Value) bool {
+  if len(a) != len(b) { return false }
+
+  for i := range a {
+    if !a[i].Equals(b[i]) { return false }
+  }
+
+  return true
+}
+
 func (v *Value) Bool() bool {
   switch v.T {
   case TypeNumber: {
@@ -1,6 +1,9 @@
 package yasp
 
-import ( "fmt" )
+import (
+  "fmt"
+  "regexp"
+)
 
 func (v *Value) AssertIdType() string {
   if v.T != TypeID { panic(fmt.Sprintf("%v expected to be ID", v)) }
@@ -26,10 +29,24 @@ func (v *Value) AssertFunctionType() *ValueFunction {
 func (v *Value) AssertStringType() string {
   if v.T != TypeString { panic(fmt.Sprintf("%v expected to be String", v)) }
 
+  if t, ok := v.V.(*Text); ok { return t.String() }
+
   s, _ := v.V.(string)
 
   return s
 }
+// AssertTextType converts string to Text once and keeps it in the value.
+func (v *Value) AssertTextType() *Text {
+  if v.T != TypeString { panic(fmt.Sprintf("%v expected to be String", v)) }
+
+  if t, ok := v.V.(*Text); ok { return t }
+
+  s, _ := v.V.(string)
+  t := NewText(s)
+  v.V = t
+
+  return t
+}
 func (v *Value) AssertExpressionType() *Stack {
   if v.T != TypeExpression { panic(fmt.Sprintf("%v expected to be Expression", v)) }
 
@@ -44,3 +61,59 @@ func (v *Value) AssertListType() []*Value {
 
   return l
 }
+func (v *Value) AssertAtomType() *Atom {
+  if v.T != TypeAtom { panic(fmt.Sprintf("%v expected to be Atom", v)) }
+
+  a, _ := v.V.(*Atom)
+
+  return a
+}
+func (v *Value) AssertStructTypeType() *StructType {
+  if v.T != TypeStructType { panic(fmt.Sprintf("%v expected to be Struct type", v)) }
+
+  st, _ := v.V.(*StructType)
+
+  return st
+}
+func (v *Value) AssertStructType() *StructValue {
+  if v.T != TypeStruct { panic(fmt.Sprintf("%v expected to be Struct", v)) }
+
+  sv, _ := v.V.(*StructValue)
+
+  return sv
+}
+func (v *Value) AssertEnumTypeType() *EnumType {
+  if v.T != TypeEnumType { panic(fmt.Sprintf("%v expected to be Enum type", v)) }
+
+  et, _ := v.V.(*EnumType)
+
+  return et
+}
+func (v *Value) AssertEnumType() *EnumValue {
+  if v.T != TypeEnum { panic(fmt.Sprintf("%v expected to be Enum", v)) }
+
+  ev, _ := v.V.(*EnumValue)
+
+  return ev
+}
+func (v *Value) AssertErrorType() *ErrorValue {
+  if v.T != TypeError { panic(fmt.Sprintf("%v expected to be Error", v)) }
+
+  ev, _ := v.V.(*ErrorValue)
+
+  return ev
+}
+func (v *Value) AssertModuleType() *Module {
+  if v.T != TypeModule { panic(fmt.Sprintf("%v expected to be Module", v)) }
+
+  m, _ := v.V.(*Module)
+
+  return m
+}
+func (v *Value) AssertRegexType() *regexp.Regexp {
+  if v.T != TypeRegex { panic(fmt.Sprintf("%v expected to be Regex", v)) }
+
+  re, _ := v.V.(*regexp.Regexp)
+
+  return re
+}
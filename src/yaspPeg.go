@@ -3,6 +3,7 @@ package yasp
 import (
   "fmt"
   "bytes"
+  "strings"
 )
 
 func (p *Parsing) Evaluate(context *EvaluationContext) *Value {
@@ -21,19 +22,66 @@ func (p *Parsing) Evaluate(context *EvaluationContext) *Value {
   return last
 }
 
+// CreateFunction parses parameters list of the form
+// (a (b c) &optional d (e 1) & rest &key f (g 2)),
+// required, optional and rest parameters can be destructuring patterns.
 func CreateFunction(context *EvaluationContext, argsStack *Stack, body *Value) *Value {
   argsExpanded := argsStack.Expand()
 
-  argsNames := make([]string, argsStack.Size)
+  fv := ValueFunction{boundContext: context, args: []*Value{}, body: body}
 
-  for i, x := range argsExpanded {
-    if x.T != TypeID { panic("list in second function argument must contain only ids") }
+  section := "required"
 
-    str, _ := x.V.(string)
-    argsNames[i] = str
+  for i := 0; i < len(argsExpanded); i++ {
+    x := argsExpanded[i]
+
+    if x.T == TypeID {
+      str, _ := x.V.(string)
+
+      switch str {
+      case "&optional", "&key": {
+        section = str
+        continue
+      }
+      case "&": {
+        if i + 1 >= len(argsExpanded) { panic("& must be followed by a name of rest parameter") }
+        if fv.rest != nil { panic("function can have only one rest parameter") }
+
+        i++
+        fv.rest = argsExpanded[i]
+        continue
+      }
+      }
+    }
+
+    switch section {
+    case "required": fv.args = append(fv.args, x)
+    case "&optional": fv.optional = append(fv.optional, createFunctionParameter(x))
+    case "&key": {
+      param := createFunctionParameter(x)
+      if param.Pattern.T != TypeID { panic(fmt.Sprintf("keyword parameter must be an id, got: %v", param.Pattern)) }
+
+      fv.keys = append(fv.keys, param)
+    }
+    }
   }
 
-  return &Value{T: TypeFunction, V: ValueFunction{boundContext: context, argsNames: argsNames, body: body}}
+  return &Value{T: TypeFunction, V: fv}
+}
+
+func createFunctionParameter(x *Value) FunctionParameter {
+  switch x.T {
+  case TypeID: return FunctionParameter{Pattern: x}
+  case TypeExpression: {
+    pair := x.AssertExpressionType()
+    if pair.Size != 2 { panic(fmt.Sprintf("parameter with default must be (name default), got: %v", x)) }
+
+    expanded := pair.Expand()
+
+    return FunctionParameter{Pattern: expanded[0], Default: expanded[1]}
+  }
+  default: panic(fmt.Sprintf("Unknown type for function parameter: %v", x.T))
+  }
 }
 
 func AssertNumberOfArguments(s *Stack, expected uint32, fnName string) {
@@ -44,6 +92,8 @@ func AssertNumberOfArguments(s *Stack, expected uint32, fnName string) {
 func (s *Stack) Evaluate(context *EvaluationContext) *Value {
   if s.Size == 0 { return &Value{T: TypeNil} }
 
+  if context.Debugger != nil { context.Debugger.before(s, context) }
+
   expanded := s.Expand()
 
   f := expanded[0].Evaluate(context)
@@ -114,8 +164,8 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
     case "ord": {
       AssertNumberOfArguments(s, 1, fnName)
 
-      x := expanded[1].Evaluate(context).AssertStringType()
-      c := uint64([]rune(x)[0])
+      x := expanded[1].Evaluate(context).AssertTextType()
+      c := uint64(x.At(0))
 
       return &Value{T: TypeNumber, V: c}
     }
@@ -185,13 +235,12 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
       if letCount % 2 != 0 { panic("let must have even number of values") }
       letCount /= 2
 
-      newContext := context.Clone()
+      newContext := context.Extend()
 
       for i := 0; i < letCount; i++ {
-        varName := expandedLet[2 * i].AssertIdType()
         varValue := expandedLet[2 * i + 1].Evaluate(newContext)
 
-        newContext.Vars[varName] = varValue
+        Destructure(newContext, expandedLet[2 * i], varValue)
       }
 
       return expanded[2].Evaluate(newContext)
@@ -210,7 +259,7 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
       x := expanded[1].Evaluate(context)
 
       switch x.T {
-      case TypeString: return &Value{T: TypeString, V: string([]rune(x.AssertStringType())[0])}
+      case TypeString: return textValue(x.AssertTextType().Slice(0, 1))
       case TypeList: {
         lst := x.AssertListType()
         return lst[0]
@@ -225,13 +274,7 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
       collection := expanded[2].Evaluate(context)
 
       switch collection.T {
-      case TypeString: {
-        s, _ := collection.V.(string)
-        runes := []rune(s)
-        taken := string(runes[:n])
-
-        return &Value{T: TypeString, V: taken}
-      }
+      case TypeString: return textValue(collection.AssertTextType().Slice(0, int(n)))
       case TypeList: {
         lst := collection.AssertListType()
         return &Value{T: TypeList, V: lst[:n]}
@@ -247,11 +290,8 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
 
       switch collection.T {
       case TypeString: {
-        s, _ := collection.V.(string)
-        runes := []rune(s)
-        skipped := string(runes[n:])
-
-        return &Value{T: TypeString, V: skipped}
+        text := collection.AssertTextType()
+        return textValue(text.Slice(int(n), text.Len()))
       }
       case TypeList: {
         lst := collection.AssertListType()
@@ -280,9 +320,10 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
       x := expanded[1].Evaluate(context)
 
       switch x.T {
-      case TypeString: {
-        s, _ := x.V.(string)
-        return &Value{T: TypeNumber, V: uint64(len([]rune(s)))}
+      case TypeString: return &Value{T: TypeNumber, V: uint64(x.AssertTextType().Len())}
+      case TypeList: {
+        lst := x.AssertListType()
+        return &Value{T: TypeNumber, V: uint64(len(lst))}
       }
       default: fmt.Printf("%v\n", x); panic(fmt.Sprintf("Unknown type for len: %v", x.T))
       }
@@ -307,11 +348,8 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
 
       switch x.T {
       case TypeString: {
-        s, _ := x.V.(string)
-        runes := []rune(s)
-        tail := string(runes[1:])
-
-        return &Value{T: TypeString, V: tail}
+        text := x.AssertTextType()
+        return textValue(text.Slice(1, text.Len()))
       }
       case TypeList: {
         lst, _ := x.V.([]*Value)
@@ -346,6 +384,14 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
       case TypeFunction: return &Value{T: TypeString, V: "function"}
       case TypeNil: return &Value{T: TypeString, V: "nil"}
       case TypeList: return &Value{T: TypeString, V: "list"}
+      case TypeAtom: return &Value{T: TypeString, V: "atom"}
+      case TypeStructType: return &Value{T: TypeString, V: "struct-type"}
+      case TypeStruct: return &Value{T: TypeString, V: "struct"}
+      case TypeEnumType: return &Value{T: TypeString, V: "enum-type"}
+      case TypeEnum: return &Value{T: TypeString, V: "enum"}
+      case TypeError: return &Value{T: TypeString, V: "error"}
+      case TypeModule: return &Value{T: TypeString, V: "module"}
+      case TypeRegex: return &Value{T: TypeString, V: "regex"}
       default: panic(fmt.Sprintf("Unknown type for typeof: %v", x.T))
       }
     }
@@ -356,8 +402,7 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
 
       switch x.T {
       case TypeString: {
-        s, _ := x.V.(string)
-        if s == "" { return &Value{T: TypeNumber, V: uint64(1)}
+        if x.AssertTextType().Len() == 0 { return &Value{T: TypeNumber, V: uint64(1)}
         } else { return &Value{T: TypeNumber, V: uint64(0)} }
       }
       case TypeList: {
@@ -421,7 +466,7 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
     case "do": {
       var last *Value
 
-      newContext := context.Clone()
+      newContext := context.Extend()
 
       for _, x := range expanded[1:] {
         last = x.Evaluate(newContext)
@@ -429,15 +474,74 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
 
       return last
     }
+    case "match": return evaluateMatch(context, s, expanded)
+    case "try": return evaluateTry(context, expanded[1:])
+    case "module": return evaluateModule(context, expanded)
+    case "import": return evaluateImport(context, s, expanded)
+    case "export": {
+      if context.module == nil { panic("export outside of module") }
+
+      for _, x := range expanded[1:] {
+        context.module.Export(x.AssertIdType())
+      }
+
+      return &Value{T: TypeNil}
+    }
+    case "throw": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      panic(&Exception{Value: expanded[1].Evaluate(context)})
+    }
+    case "error": {
+      if s.Size != 2 && s.Size != 3 { panic("error expected message and optional data") }
+
+      ev := &ErrorValue{Message: expanded[1].Evaluate(context).AssertStringType()}
+      if s.Size == 3 { ev.Data = expanded[2].Evaluate(context) }
+
+      return &Value{T: TypeError, V: ev}
+    }
+    case "error?": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      if expanded[1].Evaluate(context).T == TypeError {
+        return &Value{T: TypeNumber, V: uint64(1)}
+      } else {
+        return &Value{T: TypeNumber, V: uint64(0)}
+      }
+    }
+    case "error-message": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      return &Value{T: TypeString, V: expanded[1].Evaluate(context).AssertErrorType().Message}
+    }
+    case "error-data": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      data := expanded[1].Evaluate(context).AssertErrorType().Data
+      if data == nil { return &Value{T: TypeNil} }
+
+      return data
+    }
+    case "error-trace": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      var trace []*Value
+
+      for _, frame := range expanded[1].Evaluate(context).AssertErrorType().Trace {
+        trace = append(trace, &Value{T: TypeString, V: frame.String()})
+      }
+
+      return &Value{T: TypeList, V: trace}
+    }
     case "switch": {
       xv := expanded[1].Evaluate(context)
 
       odd := s.Size % 2
 
-      for i := uint32(0); i < (s.Size - 2) / 2 - odd; i++ {
+      for i := uint32(0); i < (s.Size - 2) / 2; i++ {
         key := expanded[2 + 2 * i]
 
-        if key.Equals(xv) {
+        if switchKeyMatches(key, xv) {
           return expanded[2 + 2 * i + 1].Evaluate(context)
         }
       }
@@ -461,6 +565,171 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
 
       return &Value{T: TypeString, V: buffer.String()}
     }
+    case "str", Interpolation: {
+      var buffer bytes.Buffer
+
+      for _, x := range expanded[1:] {
+        buffer.WriteString(x.Evaluate(context).Display())
+      }
+
+      return &Value{T: TypeString, V: buffer.String()}
+    }
+    case "repr": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      return &Value{T: TypeString, V: expanded[1].Evaluate(context).Print()}
+    }
+    case "read": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      parsing, err := Parse(expanded[1].Evaluate(context).AssertStringType(), "")
+      if err != nil { panic(err.Error()) }
+
+      forms := parsing.Forms()
+      if len(forms) != 1 { panic(fmt.Sprintf("read expected one form, got %v", len(forms))) }
+
+      return forms[0]
+    }
+    case "format": {
+      if s.Size < 2 { panic("format expected a format string") }
+
+      format := expanded[1].Evaluate(context).AssertStringType()
+
+      var args []*Value
+      for _, x := range expanded[2:] {
+        args = append(args, x.Evaluate(context))
+      }
+
+      return &Value{T: TypeString, V: formatString(format, args)}
+    }
+    case "split": {
+      AssertNumberOfArguments(s, 2, fnName)
+
+      separator := expanded[1].Evaluate(context).AssertStringType()
+      str := expanded[2].Evaluate(context).AssertStringType()
+
+      return stringList(strings.Split(str, separator))
+    }
+    case "join": {
+      AssertNumberOfArguments(s, 2, fnName)
+
+      separator := expanded[1].Evaluate(context).AssertStringType()
+      lst := expanded[2].Evaluate(context).AssertListType()
+
+      return &Value{T: TypeString, V: strings.Join(stringValues(lst), separator)}
+    }
+    case "replace": {
+      AssertNumberOfArguments(s, 3, fnName)
+
+      old := expanded[1].Evaluate(context).AssertStringType()
+      replacement := expanded[2].Evaluate(context).AssertStringType()
+      str := expanded[3].Evaluate(context).AssertStringType()
+
+      return &Value{T: TypeString, V: strings.Replace(str, old, replacement, -1)}
+    }
+    case "index-of": {
+      AssertNumberOfArguments(s, 2, fnName)
+
+      needle := expanded[1].Evaluate(context).AssertStringType()
+      str := expanded[2].Evaluate(context).AssertStringType()
+
+      return runeIndex(str, strings.Index(str, needle))
+    }
+    case "starts-with?": {
+      AssertNumberOfArguments(s, 2, fnName)
+
+      prefix := expanded[1].Evaluate(context).AssertStringType()
+      str := expanded[2].Evaluate(context).AssertStringType()
+
+      return boolValue(strings.HasPrefix(str, prefix))
+    }
+    case "ends-with?": {
+      AssertNumberOfArguments(s, 2, fnName)
+
+      suffix := expanded[1].Evaluate(context).AssertStringType()
+      str := expanded[2].Evaluate(context).AssertStringType()
+
+      return boolValue(strings.HasSuffix(str, suffix))
+    }
+    case "trim": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      return &Value{T: TypeString, V: strings.TrimSpace(expanded[1].Evaluate(context).AssertStringType())}
+    }
+    case "upper": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      return &Value{T: TypeString, V: strings.ToUpper(expanded[1].Evaluate(context).AssertStringType())}
+    }
+    case "lower": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      return &Value{T: TypeString, V: strings.ToLower(expanded[1].Evaluate(context).AssertStringType())}
+    }
+    case "substring": {
+      AssertNumberOfArguments(s, 3, fnName)
+
+      start := expanded[1].Evaluate(context).AssertNumberType()
+      end := expanded[2].Evaluate(context).AssertNumberType()
+      text := expanded[3].Evaluate(context).AssertTextType()
+
+      return textValue(substring(text, start, end))
+    }
+    case "chr": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      return &Value{T: TypeString, V: string(rune(expanded[1].Evaluate(context).AssertNumberType()))}
+    }
+    case "repeat": {
+      AssertNumberOfArguments(s, 2, fnName)
+
+      n := expanded[1].Evaluate(context).AssertNumberType()
+      str := expanded[2].Evaluate(context).AssertStringType()
+
+      return &Value{T: TypeString, V: strings.Repeat(str, int(n))}
+    }
+    case "regex": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      return compileRegex(expanded[1].Evaluate(context).AssertStringType())
+    }
+    case "re-match": {
+      AssertNumberOfArguments(s, 2, fnName)
+
+      re := expanded[1].Evaluate(context).asRegex()
+
+      return reMatch(re, expanded[2].Evaluate(context).AssertStringType())
+    }
+    case "re-find": {
+      AssertNumberOfArguments(s, 2, fnName)
+
+      re := expanded[1].Evaluate(context).asRegex()
+
+      return groupsList(re.FindStringSubmatch(expanded[2].Evaluate(context).AssertStringType()))
+    }
+    case "re-find-all": {
+      AssertNumberOfArguments(s, 2, fnName)
+
+      re := expanded[1].Evaluate(context).asRegex()
+
+      return reFindAll(re, expanded[2].Evaluate(context).AssertStringType())
+    }
+    case "re-replace": {
+      AssertNumberOfArguments(s, 3, fnName)
+
+      re := expanded[1].Evaluate(context).asRegex()
+      replacement := expanded[2].Evaluate(context).AssertStringType()
+      str := expanded[3].Evaluate(context).AssertStringType()
+
+      return &Value{T: TypeString, V: re.ReplaceAllString(str, replacement)}
+    }
+    case "re-split": {
+      AssertNumberOfArguments(s, 2, fnName)
+
+      re := expanded[1].Evaluate(context).asRegex()
+
+      return stringList(re.Split(expanded[2].Evaluate(context).AssertStringType(), -1))
+    }
     case "append": {
       AssertNumberOfArguments(s, 2, fnName)
 
@@ -491,11 +760,11 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
     }
     case "print": {
       first := expanded[1].Evaluate(context)
-      fmt.Println(first.V)
+      fmt.Println(first.Display())
 
       for _, x := range expanded[2:] {
         xv := x.Evaluate(context)
-        fmt.Println(xv.V)
+        fmt.Println(xv.Display())
       }
 
       return first
@@ -520,10 +789,58 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
     }
     case "def": {
       key := expanded[1].AssertIdType()
-      context.Vars[key] = expanded[2].Evaluate(context)
+      context.Define(key, expanded[2].Evaluate(context))
 
       return expanded[2]
     }
+    case "set!": {
+      AssertNumberOfArguments(s, 2, fnName)
+
+      key := expanded[1].AssertIdType()
+      value := expanded[2].Evaluate(context)
+
+      if !context.Set(key, value) { panic(fmt.Sprintf("set! of unbound variable: %v", key)) }
+
+      return value
+    }
+    case "quote": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      return expanded[1]
+    }
+    case "atom": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      return &Value{T: TypeAtom, V: &Atom{Value: expanded[1].Evaluate(context)}}
+    }
+    case "deref": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      return expanded[1].Evaluate(context).AssertAtomType().Value
+    }
+    case "reset!": {
+      AssertNumberOfArguments(s, 2, fnName)
+
+      atom := expanded[1].Evaluate(context).AssertAtomType()
+      atom.Value = expanded[2].Evaluate(context)
+
+      return atom.Value
+    }
+    case "swap!": {
+      if s.Size < 3 { panic("swap! expected at least 2 args") }
+
+      atom := expanded[1].Evaluate(context).AssertAtomType()
+      f := expanded[2].Evaluate(context)
+
+      args := []*Value{atom.Value}
+      for _, x := range expanded[3:] {
+        args = append(args, x.Evaluate(context))
+      }
+
+      atom.Value = Apply(context, f, args)
+
+      return atom.Value
+    }
     case "defn": {
       if expanded[1].T != TypeID { panic(fmt.Sprintf("Expected ID, got: %v", expanded[1].T)) }
       if expanded[2].T != TypeExpression { panic("second function arguments must be a list") }
@@ -532,10 +849,104 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
       fnArgs, _ := expanded[2].V.(*Stack)
 
       fun := CreateFunction(context, fnArgs, expanded[3])
+      fun.V = fun.AssertFunctionType().withName(fnName)
 
-      context.Vars[fnName] = fun
+      context.Define(fnName, fun)
       return fun
     }
+    case "defstruct": {
+      if s.Size < 2 { panic("defstruct expected a name") }
+
+      name := expanded[1].AssertIdType()
+      st := &Value{T: TypeStructType, V: CreateStructType(name, expanded[2:])}
+
+      context.Define(name, st)
+      return st
+    }
+    case "defenum": {
+      if s.Size < 2 { panic("defenum expected a name") }
+
+      name := expanded[1].AssertIdType()
+      et := &Value{T: TypeEnumType, V: CreateEnumType(name, expanded[2:])}
+
+      context.Define(name, et)
+      return et
+    }
+    case "map": {
+      if s.Size < 3 { panic("map expected a function and collections") }
+
+      f := expanded[1].Evaluate(context)
+
+      var collections []*Value
+      for _, x := range expanded[2:] {
+        collections = append(collections, x.Evaluate(context))
+      }
+
+      return mapCollection(context, f, collections)
+    }
+    case "filter": {
+      AssertNumberOfArguments(s, 2, fnName)
+
+      return filterCollection(context, expanded[1].Evaluate(context), expanded[2].Evaluate(context))
+    }
+    case "reduce": {
+      if s.Size != 3 && s.Size != 4 { panic("reduce expected a function, optional initial value and a collection") }
+
+      f := expanded[1].Evaluate(context)
+      elements := Elements(expanded[s.Size - 1].Evaluate(context))
+
+      if s.Size == 4 { return reduceCollection(context, f, expanded[2].Evaluate(context), elements) }
+
+      if len(elements) == 0 { panic("reduce of empty collection without initial value") }
+
+      return reduceCollection(context, f, elements[0], elements[1:])
+    }
+    case "range": {
+      var start, end, step uint64 = 0, 0, 1
+
+      switch s.Size {
+      case 2: end = expanded[1].Evaluate(context).AssertNumberType()
+      case 3, 4: {
+        start = expanded[1].Evaluate(context).AssertNumberType()
+        end = expanded[2].Evaluate(context).AssertNumberType()
+
+        if s.Size == 4 { step = expanded[3].Evaluate(context).AssertNumberType() }
+      }
+      default: panic("range expected end, start and end, or start, end and step")
+      }
+
+      return rangeList(start, end, step)
+    }
+    case "reverse": {
+      AssertNumberOfArguments(s, 1, fnName)
+
+      return reverseCollection(expanded[1].Evaluate(context))
+    }
+    case "zip": {
+      var collections []*Value
+      for _, x := range expanded[1:] {
+        collections = append(collections, x.Evaluate(context))
+      }
+
+      return zipCollections(collections)
+    }
+    case "sort": {
+      switch s.Size {
+      case 2: return sortCollection(context, nil, expanded[1].Evaluate(context))
+      case 3: return sortCollection(context, expanded[1].Evaluate(context), expanded[2].Evaluate(context))
+      default: panic("sort expected optional less function and a collection")
+      }
+    }
+    case "any?": {
+      AssertNumberOfArguments(s, 2, fnName)
+
+      return boolValue(anyInCollection(context, expanded[1].Evaluate(context), expanded[2].Evaluate(context), true))
+    }
+    case "all?": {
+      AssertNumberOfArguments(s, 2, fnName)
+
+      return boolValue(!anyInCollection(context, expanded[1].Evaluate(context), expanded[2].Evaluate(context), false))
+    }
     case "list": {
       var lst []*Value
 
@@ -549,7 +960,18 @@ func (s *Stack) Evaluate(context *EvaluationContext) *Value {
     default: panic(fmt.Sprintf("unknown f: %v", fnName))
     }
   }
-  case TypeFunction: { return f.EvaluateFunction(context, expanded[1:]) }
+  case TypeFunction: { return f.evaluateCall(context, expanded[1:], s.Pos) }
+  case TypeStructType: { return f.AssertStructTypeType().Construct(context, expanded[1:]) }
+  case TypeStruct: {
+    AssertNumberOfArguments(s, 1, "struct field access")
+
+    return f.AssertStructType().Get(expanded[1].AssertIdType())
+  }
+  case TypeEnumType: {
+    AssertNumberOfArguments(s, 1, "enum construction")
+
+    return f.AssertEnumTypeType().Construct(expanded[1])
+  }
   default: fmt.Printf("x: %v\n", expanded); panic(fmt.Sprintf("Unknown type: %v", f.T))
   }
 
@@ -559,12 +981,19 @@ func (v *Value) Evaluate(context *EvaluationContext) *Value {
   switch v.T {
   case TypeID: {
     key, _ := v.V.(string)
-    val, ok := context.Vars[key]
+    val, ok := context.Lookup(key)
 
     if ok { return val }
-          { return v }
+
+    if context.Strict && !isKeyword(key) {
+      if _, builtin := Builtins[key]; !builtin { panic(fmt.Sprintf("unbound variable %v at %v", key, v.Pos)) }
+    }
+
+    return v
   }
-  case TypeNumber, TypeString: return v
+  case TypeNumber, TypeString, TypeList, TypeFunction, TypeNil, TypeAtom,
+       TypeStructType, TypeStruct, TypeEnumType, TypeEnum, TypeError,
+       TypeModule, TypeRegex: return v
   case TypeExpression: {
     s, _ := v.V.(*Stack)
     return s.Evaluate(context)
@@ -574,19 +1003,158 @@ func (v *Value) Evaluate(context *EvaluationContext) *Value {
 }
 
 func (v *Value) EvaluateFunction(context *EvaluationContext, args []*Value) *Value {
+  return v.evaluateCall(context, args, Position{})
+}
+
+// evaluateCall evaluates arguments and calls the function, pos is the call
+// site shown in traces.
+func (v *Value) evaluateCall(context *EvaluationContext, args []*Value, pos Position) *Value {
+  values := make([]*Value, len(args))
+
+  for i, x := range args {
+    values[i] = x.Evaluate(context)
+  }
+
+  return v.call(context, values, pos)
+}
+
+// Call invokes a user function with already evaluated arguments.
+func (v *Value) Call(context *EvaluationContext, args []*Value) *Value {
+  return v.call(context, args, Position{})
+}
+
+func (v *Value) call(context *EvaluationContext, args []*Value, pos Position) *Value {
   fv := v.AssertFunctionType()
-  argsCount := uint32(len(args))
-  newContext := EmptyEvaluationContext()
-  for k, v := range fv.boundContext.Vars {
-    newContext.Vars[k] = v
+  newContext := fv.boundContext.Extend()
+  newContext.calls = context.calls
+  newContext.Debugger = context.Debugger
+
+  // not deferred, frames of a failed call stay for the error trace
+  context.calls.push(Frame{Name: fv.Name(), Pos: pos, Args: args})
+  if context.Debugger != nil { context.Debugger.enter(context.calls.Depth()) }
+
+  fv.bindArguments(newContext, args)
+  result := fv.body.Evaluate(newContext)
+
+  context.calls.pop()
+
+  return result
+}
+
+func (fv *ValueFunction) bindArguments(context *EvaluationContext, args []*Value) {
+  keywords := make(map[string]*Value)
+
+  if len(fv.keys) > 0 {
+    var positional []*Value
+
+    for i := 0; i < len(args); i++ {
+      if i + 1 < len(args) && fv.hasKey(args[i]) {
+        keywords[args[i].AssertIdType()[1:]] = args[i + 1]
+        i++
+      } else {
+        positional = append(positional, args[i])
+      }
+    }
+
+    args = positional
+  }
+
+  argsCount := len(args)
+  required := len(fv.args)
+
+  if argsCount < required || (fv.rest == nil && argsCount > required + len(fv.optional)) {
+    panic(fmt.Sprintf("argument count missmatch: expected %v, got %v %v", fv.arity(), argsCount, args))
+  }
+
+  for i, pattern := range fv.args {
+    Destructure(context, pattern, args[i])
+  }
+
+  args = args[required:]
+
+  for _, param := range fv.optional {
+    if len(args) > 0 {
+      Destructure(context, param.Pattern, args[0])
+      args = args[1:]
+    } else {
+      Destructure(context, param.Pattern, param.defaultValue(context))
+    }
+  }
+
+  if fv.rest != nil {
+    Destructure(context, fv.rest, &Value{T: TypeList, V: append([]*Value{}, args...)})
+  }
+
+  for _, param := range fv.keys {
+    name := param.Pattern.AssertIdType()
+
+    if value, ok := keywords[name]; ok {
+      context.Define(name, value)
+    } else {
+      context.Define(name, param.defaultValue(context))
+    }
   }
+}
 
-  if (argsCount != uint32(len(fv.argsNames))) { panic(fmt.Sprintf("argument count missmatch %v", args)) }
+func (fv *ValueFunction) hasKey(v *Value) bool {
+  if v.T != TypeID { return false }
 
-  for i, v := range args {
-    xv := v.Evaluate(context)
-    newContext.Vars[fv.argsNames[i]] = xv
+  s, _ := v.V.(string)
+
+  for _, param := range fv.keys {
+    if s == ":" + param.Pattern.AssertIdType() { return true }
   }
 
-  return fv.body.Evaluate(newContext)
+  return false
+}
+
+func (fv *ValueFunction) arity() string {
+  required := len(fv.args)
+
+  switch {
+  case fv.rest != nil: return fmt.Sprintf("at least %v", required)
+  case len(fv.optional) > 0: return fmt.Sprintf("%v to %v", required, required + len(fv.optional))
+  default: return fmt.Sprint(required)
+  }
+}
+
+// Defaults are evaluated at call time and can refer to previous parameters.
+func (p FunctionParameter) defaultValue(context *EvaluationContext) *Value {
+  if p.Default == nil { return &Value{T: TypeNil} }
+
+  return p.Default.Evaluate(context)
+}
+
+// Apply calls either a user function or a builtin with already evaluated
+// arguments, so builtins can be passed around as callbacks.
+func Apply(context *EvaluationContext, f *Value, args []*Value) *Value {
+  switch f.T {
+  case TypeFunction: return f.Call(context, args)
+  case TypeID: {
+    // non-root stack, so a call without arguments is not taken for a bare value
+    call := CreateStack(CreateStack(nil))
+    call.AddToStack(*f)
+
+    for _, x := range args {
+      call.AddToStack(*Quote(x))
+    }
+
+    return call.Evaluate(context)
+  }
+  default: panic(fmt.Sprintf("%v is not a function", f))
+  }
+}
+
+// Quote wraps values that are not self-evaluating into (quote x).
+func Quote(v *Value) *Value {
+  switch v.T {
+  case TypeID, TypeExpression: {
+    s := CreateStack(nil)
+    s.AddToStack(Value{T: TypeID, V: "quote"})
+    s.AddToStack(*v)
+
+    return &Value{T: TypeExpression, V: s}
+  }
+  default: return v
+  }
 }
@@ -22,7 +22,8 @@ func AssertString(t *testing.T, expected string, actual *Value) {
     t.Error("Expected TypeString, got: ", actual.T)
   }
 
-  s, _ := actual.V.(string)
+  var s string
+  if actual.T == TypeString { s = actual.AssertStringType() }
 
   if s != expected {
     t.Error("Expected ", expected, ", got: ", s)
@@ -0,0 +1,55 @@
+package yasp
+
+import (
+  "testing"
+
+  //. "../src";
+  . "../util";
+)
+
+func TestMapFilterReduce(t *testing.T) {
+  var expected uint64 = 3 * 3 + 4 * 4
+  actual := ParseAndEvaluate("(reduce + 0 (map (fn (x) (* x x)) (filter (fn (x) (>= x 3)) (range 1 5))))")
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestMapBuiltin(t *testing.T) {
+  var expected uint64 = 11 + 22
+  actual := ParseAndEvaluate("(reduce + (map + (list 1 2) (list 10 20 30)))")
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestStringCollection(t *testing.T) {
+  var expected string = "cba"
+  actual := ParseAndEvaluate("(reverse (filter (fn (c) (not (= c '-'))) 'a-b-c'))")
+
+  AssertString(t, expected, actual)
+}
+
+func TestSort(t *testing.T) {
+  var expected string = "a ccc c"
+  actual := ParseAndEvaluate(`
+(concat
+  (head (sort (list 'b' 'c' 'a'))) ' '
+  (last (sort (fn (a b) (< (len a) (len b))) (list 'ccc' 'b' 'aa'))) ' '
+  (get 1 (last (sort (zip (list 2 1 3) (list 'a' 'b' 'c'))))))`)
+
+  AssertString(t, expected, actual)
+}
+
+func TestAnyAll(t *testing.T) {
+  var expected uint64 = 1 + 0 + 1
+  actual := ParseAndEvaluate("(+ (any? isDigit 'ab1') (all? isDigit 'ab1') (all? isDigit ''))")
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestRangeNearLargestNumber(t *testing.T) {
+  var expected uint64 = 18446744073709551614
+  actual := ParseAndEvaluate("(last (range 0 18446744073709551615 9223372036854775807))")
+
+  AssertNumber(t, expected, actual)
+  AssertNumber(t, 2, ParseAndEvaluate("(len (range 18446744073709551613 18446744073709551615))"))
+}
@@ -0,0 +1,38 @@
+package yasp
+
+import (
+  "testing"
+
+  . "../src";
+  . "../util";
+)
+
+func TestComments(t *testing.T) {
+  var expected uint64 = 5
+  actual := ParseAndEvaluate(`// leading comment
+(+ 2 /* inline /* nested */ comment */ 3 #_(+ 100 #_ 200)) // 5
+/* trailing */`)
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestCommentPositions(t *testing.T) {
+  parsing, err := Parse("(a) // one\n  /* two */ #_ b\n", "file.yasp")
+  if err != nil { t.Fatal(err) }
+
+  expected := []struct { text string; pos string } {
+    {"// one", "file.yasp:1:5"},
+    {"/* two */", "file.yasp:2:3"},
+    {"#_b", "file.yasp:2:13"},
+  }
+
+  if len(parsing.Comments) != len(expected) {
+    t.Fatal("Expected ", len(expected), " comments, got: ", parsing.Comments)
+  }
+
+  for i, comment := range parsing.Comments {
+    if comment.Text != expected[i].text || comment.Pos.String() != expected[i].pos {
+      t.Error("Expected ", expected[i], ", got: ", comment.Text, " at ", comment.Pos)
+    }
+  }
+}
@@ -0,0 +1,132 @@
+package yasp
+
+import (
+  "fmt"
+  "strings"
+  "testing"
+
+  . "../src";
+)
+
+const debuggedSource = `(defn area (w h)
+  (* w h))
+(def x (area 2 3))
+(+ x 1)`
+
+// debug evaluates debuggedSource, commands choose how to resume at
+// each pause and the pauses are returned as file:line reason frames.
+func debug(t *testing.T, setup func (d *Debugger), commands ...func (d *Debugger, p *Pause)) []string {
+  parsing, err := Parse(debuggedSource, "main.yasp")
+  if err != nil { t.Fatal(err) }
+
+  pauses := []string{}
+
+  d := NewDebugger()
+  setup(d)
+  d.OnPause = func (p *Pause) {
+    frames := []string{}
+    for _, f := range p.Frames { frames = append(frames, f.String()) }
+
+    pauses = append(pauses, fmt.Sprintf("%v:%v %v [%v]", p.Pos.File, p.Pos.Line, p.Reason, strings.Join(frames, " ")))
+
+    if len(commands) > 0 {
+      commands[0](d, p)
+      commands = commands[1:]
+    }
+  }
+
+  context := EmptyEvaluationContext()
+  context.Debugger = d
+  parsing.Evaluate(context)
+
+  return pauses
+}
+
+func assertPauses(t *testing.T, expected []string, actual []string) {
+  if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+    t.Errorf("Expected pauses:\n%v\ngot:\n%v", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+  }
+}
+
+func TestDebuggerBreakpoint(t *testing.T) {
+  var w, missing, empty *Value
+
+  pauses := debug(t, func (d *Debugger) { d.SetBreakpoint("main.yasp", 2) }, func (d *Debugger, p *Pause) {
+    w, missing, empty = p.Eval("(+ w 1)"), p.Eval("(+ (quote a) 1)"), p.Eval("  ")
+  })
+
+  assertPauses(t, []string{"main.yasp:2 breakpoint [area]"}, pauses)
+  AssertNumber(t, 3, w)
+  if missing.T != TypeError { t.Errorf("Expected an error, got %v", missing) }
+  if empty.T != TypeError || empty.Print() == "" { t.Errorf("Expected an error, got %v", empty) }
+}
+
+func TestDebuggerStepping(t *testing.T) {
+  stepIn := func (d *Debugger, p *Pause) { d.StepIn() }
+  stepOver := func (d *Debugger, p *Pause) { d.StepOver() }
+
+  assertPauses(t, []string{
+    "main.yasp:1 step []",
+    "main.yasp:3 step []",
+    "main.yasp:2 step [area]",
+    "main.yasp:4 step []",
+  }, debug(t, (*Debugger).StepIn, stepIn, stepIn, stepOver))
+
+  assertPauses(t, []string{
+    "main.yasp:3 breakpoint []",
+    "main.yasp:4 step []",
+  }, debug(t, func (d *Debugger) { d.SetBreakpoint("main.yasp", 3) }, stepOver))
+
+  assertPauses(t, []string{
+    "main.yasp:2 breakpoint [area]",
+    "main.yasp:4 step []",
+  }, debug(t, func (d *Debugger) { d.SetBreakpoint("main.yasp", 2) }, func (d *Debugger, p *Pause) { d.StepOut() }))
+}
+
+func TestDebuggerLocals(t *testing.T) {
+  names := []string{}
+
+  debug(t, func (d *Debugger) { d.SetBreakpoint("main.yasp", 2) }, func (d *Debugger, p *Pause) {
+    for _, scope := range p.Locals() {
+      for name, value := range scope { names = append(names, name + "=" + value.Print()) }
+    }
+  })
+
+  if len(names) < 2 || !strings.Contains(strings.Join(names, " "), "w=2") { t.Errorf("Expected w and h in locals, got %v", names) }
+}
+
+func breakpointStops(t *testing.T, source string, line int) []string {
+  parsing, err := Parse(source, "main.yasp")
+  if err != nil { t.Fatal(err) }
+
+  stops := []string{}
+
+  d := NewDebugger()
+  d.SetBreakpoint("main.yasp", line)
+  d.OnPause = func (p *Pause) {
+    if len(p.Frames) > 0 { stops = append(stops, p.Frames[0].Describe()) }
+  }
+
+  context := EmptyEvaluationContext()
+  context.Debugger = d
+  parsing.Evaluate(context)
+
+  return stops
+}
+
+func TestDebuggerBreakpointInMappedFunction(t *testing.T) {
+  stops := breakpointStops(t, "(defn inc1 (x)\n  (+ x 1))\n(map inc1 (list 1 2 3))", 2)
+
+  assertPauses(t, []string{"(inc1 1)", "(inc1 2)", "(inc1 3)"}, stops)
+}
+
+func TestDebuggerBreakpointInRecursion(t *testing.T) {
+  stops := breakpointStops(t, "(defn f (n) (if (= n 0) 0 (f (- n 1))))\n(f 3)", 1)
+
+  assertPauses(t, []string{
+    "(f 3) at main.yasp:2:1",
+    "(f 2) at main.yasp:1:27",
+    "(f 1) at main.yasp:1:27",
+    "(f 0) at main.yasp:1:27",
+  }, stops)
+}
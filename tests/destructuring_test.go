@@ -0,0 +1,41 @@
+package yasp
+
+import (
+  "testing"
+
+  //. "../src";
+  . "../util";
+)
+
+func TestLetDestructuring(t *testing.T) {
+  var expected uint64 = 1 + 2 + 1
+  actual := ParseAndEvaluate("(let ((ok rest (a & more)) (list 1 2 (list 3 4))) (+ ok rest (len more)))")
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestFnDestructuring(t *testing.T) {
+  var expected uint64 = 5
+  actual := ParseAndEvaluate("(defn sum-pair ((a b)) (+ a b))\n(sum-pair (list 2 3))")
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestStructDestructuring(t *testing.T) {
+  var expected uint64 = 3 + (4 - 1) + 3
+  actual := ParseAndEvaluate(`
+(defstruct Point x y)
+(let ((Point a b) (Point 3 4)
+      (Point :y c) (Point y 1 x 2))
+  (+ a (- b c) 3))`)
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestDestructuringMismatch(t *testing.T) {
+  defer func() {
+    if r := recover(); r == nil { t.Error("Expected shape mismatch") }
+  }()
+
+  ParseAndEvaluate("(let ((a b) (list 1 2 3)) a)")
+}
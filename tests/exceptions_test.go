@@ -0,0 +1,46 @@
+package yasp
+
+import (
+  "testing"
+
+  //. "../src";
+  . "../util";
+)
+
+func TestThrowCatch(t *testing.T) {
+  var expected uint64 = 42
+  actual := ParseAndEvaluate("(try (+ 1 (throw (list 'oops' 42))) (catch (tag code) code))")
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestCatchRuntimeError(t *testing.T) {
+  var expected string = "argument count missmatch: expected 2, got 1 [1]"
+  actual := ParseAndEvaluate("(defn f (a b) a)\n(try (f 1) (catch e (error-message e)))")
+
+  AssertString(t, expected, actual)
+}
+
+func TestErrorTrace(t *testing.T) {
+  var expected string = "inner outer"
+  actual := ParseAndEvaluate(`
+(defn inner (x) (throw (error 'failed' x)))
+(defn outer (x) (inner x))
+(try (outer 1)
+  (catch e (let ((a b) (error-trace e)) (concat a ' ' b))))`)
+
+  AssertString(t, expected, actual)
+}
+
+func TestFinally(t *testing.T) {
+  var expected uint64 = 2
+  actual := ParseAndEvaluate(`
+(def cleaned (atom 0))
+(try
+  (try (throw 1) (finally (swap! cleaned + 1)))
+  (catch x (swap! cleaned + x)))
+(try 5 (catch e 0) (finally (swap! cleaned * 1)))
+(deref cleaned)`)
+
+  AssertNumber(t, expected, actual)
+}
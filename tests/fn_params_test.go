@@ -0,0 +1,32 @@
+package yasp
+
+import (
+  "testing"
+
+  //. "../src";
+  . "../util";
+)
+
+func TestRestParameter(t *testing.T) {
+  var expected uint64 = 3
+  actual := ParseAndEvaluate("(defn count (first & rest) (+ first (len rest)))\n(count 1 'a' 'b')")
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestOptionalParameter(t *testing.T) {
+  var expected uint64 = 5 + 6 + 20
+  actual := ParseAndEvaluate("(defn add (a &optional (b 10) (c b)) (+ a b c))\n(+ (add 1 2) (add 1 2 3) (add 0))")
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestKeywordParameter(t *testing.T) {
+  var expected string = "a-b!"
+  actual := ParseAndEvaluate(`
+(defn wrap (a b &key (sep ' ') (end ''))
+  (concat a sep b end))
+(wrap 'a' :end '!' 'b' :sep '-')`)
+
+  AssertString(t, expected, actual)
+}
@@ -0,0 +1,150 @@
+package yasp
+
+import (
+  "fmt"
+  "io/ioutil"
+  "math/rand"
+  "strings"
+  "testing"
+
+  . "../src";
+)
+
+func assertFormat(t *testing.T, width int, source string, expected string) {
+  actual, err := Format(source, "", width)
+  if err != nil { t.Fatal(err) }
+
+  if actual != expected { t.Errorf("Expected:\n%v\ngot:\n%v", expected, actual) }
+}
+
+func TestFormatShortForms(t *testing.T) {
+  assertFormat(t, 80, "(+   1\n  2)\n\n\n\n(print  \"a ${b}\"  0x1F \\space)", "(+ 1 2)\n\n(print \"a ${b}\" 0x1F \\space)\n")
+}
+
+func TestFormatSpecialForms(t *testing.T) {
+  source := `(defn f (a b) (let (x (+ a 1) longer (* b 2)) (if (= x longer) (print x) (print longer))))
+(switch x 1 'one' 2 'two' 'many')`
+
+  expected := `(defn f (a b)
+  (let (x      (+ a 1)
+        longer (* b 2))
+    (if (= x longer)
+        (print x)
+        (print longer))))
+(switch x
+  1 'one'
+  2 'two'
+  'many')
+`
+
+  assertFormat(t, 24, source, expected)
+}
+
+func TestFormatFillsAtoms(t *testing.T) {
+  assertFormat(t, 20, "(list 1 2 3 4 5 6 7 8 9 10 11 12)", "(list 1 2 3 4 5 6 7\n      8 9 10 11 12)\n")
+}
+
+func TestFormatKeepsComments(t *testing.T) {
+  source := `// leading
+
+(defn f (a) // params
+  /* body */ (+ a
+  #_ ignored 1) // trailing
+  // last
+)`
+
+  expected := `// leading
+
+(defn f (a) // params
+  /* body */
+  (+ a
+     #_ ignored 1) // trailing
+  // last
+)
+`
+
+  assertFormat(t, 80, source, expected)
+
+  // comments inside interpolations stay in the string only
+  assertFormat(t, 80, "(f \"${(g /* c */ 1)}\")", "(f \"${(g /* c */ 1)}\")\n")
+  assertFormat(t, 80, "\"${a // c\n}\" b", "\"${a // c\n}\"\nb\n")
+}
+
+func TestFormatIsIdempotentAndKeepsCode(t *testing.T) {
+  source, err := ioutil.ReadFile("../yasp.yasp")
+  if err != nil { t.Fatal(err) }
+
+  formatted, err := Format(string(source), "yasp.yasp", 80)
+  if err != nil { t.Fatal(err) }
+
+  again, _ := Format(formatted, "yasp.yasp", 80)
+  if again != formatted { t.Error("Expected formatting to be idempotent:\n", Diff("yasp.yasp", formatted, again)) }
+
+  before, _ := Parse(string(source), "")
+  after, _ := Parse(formatted, "")
+
+  if !(&Value{T: TypeList, V: before.Forms()}).Equals(&Value{T: TypeList, V: after.Forms()}) {
+    t.Error("Expected the same forms after formatting")
+  }
+}
+
+func TestDiff(t *testing.T) {
+  var expected string = "--- f\n+++ f (formatted)\n@@ -2,1 +2,2 @@\n-b\n+B\n+c\n"
+  actual := Diff("f", "a\nb\nd\n", "a\nB\nc\nd\n")
+
+  if actual != expected { t.Error("Expected ", expected, ", got: ", actual) }
+}
+
+// applyDiff rebuilds the new text from the old one and hunks of Diff.
+func applyDiff(a string, diff string) string {
+  as := strings.SplitAfter(a, "\n")
+  lines := strings.SplitAfter(diff, "\n")[2:]
+  result := []string{}
+  next := 0
+
+  for i := 0; i < len(lines) && lines[i] != ""; i++ {
+    var start, removed, bstart, added int
+    fmt.Sscanf(lines[i], "@@ -%d,%d +%d,%d @@", &start, &removed, &bstart, &added)
+
+    result = append(result, as[next:start - 1]...)
+    next = start - 1 + removed
+    i += removed
+
+    for ; added > 0; added-- {
+      i++
+      result = append(result, lines[i][1:])
+    }
+  }
+
+  return strings.Join(append(result, as[next:]...), "")
+}
+
+func TestDiffRandom(t *testing.T) {
+  random := rand.New(rand.NewSource(1))
+  text := func () string {
+    lines := []string{}
+    for n := random.Intn(30); n > 0; n-- { lines = append(lines, string(rune('a' + random.Intn(4))) + "\n") }
+    return strings.Join(lines, "")
+  }
+
+  for i := 0; i < 500; i++ {
+    a, b := text(), text()
+    diff := Diff("f", a, b)
+
+    if a != b && applyDiff(a, diff) != b {
+      t.Fatalf("Diff of %q and %q doesn't apply:\n%v", a, b, diff)
+    }
+  }
+}
+
+func TestDiffLargeFile(t *testing.T) {
+  lines := []string{}
+  for i := 0; i < 20000; i++ { lines = append(lines, fmt.Sprintf("(print %v)", i)) }
+
+  a := strings.Join(lines, "\n") + "\n"
+  lines[10000] = "(print 'changed')"
+  b := strings.Join(lines, "\n") + "\n"
+
+  expected := "--- f\n+++ f (formatted)\n@@ -10001,1 +10001,1 @@\n-(print 10000)\n+(print 'changed')\n"
+  if actual := Diff("f", a, b); actual != expected { t.Error("Expected ", expected, ", got: ", actual) }
+}
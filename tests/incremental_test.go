@@ -0,0 +1,143 @@
+package yasp
+
+import (
+  "io"
+  "runtime"
+  "testing"
+  "time"
+
+  . "../src";
+)
+
+func TestIncrementalReader(t *testing.T) {
+  reader := NewIncrementalReader("")
+
+  reader.Feed("(+ 1\n")
+  if _, err := reader.Next(); err != ErrIncomplete { t.Fatal("Expected ErrIncomplete, got: ", err) }
+  if !reader.Pending() { t.Error("Expected pending form") }
+
+  reader.Feed("  2) abc")
+  form, err := reader.Next()
+  if err != nil { t.Fatal(err) }
+
+  var expected string = "[ +, 1, 2 ]"
+  if form.String() != expected { t.Error("Expected ", expected, ", got: ", form) }
+
+  // abc may continue in the next chunk
+  if _, err := reader.Next(); err != ErrIncomplete { t.Fatal("Expected ErrIncomplete, got: ", err) }
+
+  reader.Feed("def 'x\n")
+  form, err = reader.Next()
+  if err != nil || form.String() != "abcdef" || form.Pos.String() != "2:6" {
+    t.Fatal("Expected abcdef at 2:6, got: ", form, err)
+  }
+
+  reader.Feed("'")
+  reader.Close()
+
+  form, err = reader.Next()
+  if err != nil || form.AssertStringType() != "x\n" { t.Fatal("Expected 'x\\n', got: ", form, err) }
+
+  if _, err := reader.Next(); err != io.EOF { t.Error("Expected EOF, got: ", err) }
+}
+
+func TestIncrementalReaderErrors(t *testing.T) {
+  reader := NewIncrementalReader("")
+
+  reader.Feed("(a)) b\n(c")
+  reader.Close()
+
+  if form, err := reader.Next(); err != nil || form.String() != "[ a ]" { t.Fatal("Expected (a), got: ", form, err) }
+
+  _, err := reader.Next()
+  e, ok := err.(*ParseError)
+  if !ok || e.Pos.String() != "1:4" || e.Excerpt != "  (a)) b\n     ^" { t.Fatal("Expected error at 1:4, got: ", err) }
+
+  // reading continues right after the error
+  if form, err := reader.Next(); err != nil || form.String() != "b" { t.Fatal("Expected b, got: ", form, err) }
+
+  _, err = reader.Next()
+  e, ok = err.(*ParseError)
+  if !ok || e.Message != "unclosed '('" || e.Pos.String() != "2:1" { t.Fatal("Expected unclosed '(' at 2:1, got: ", err) }
+}
+
+func TestIncrementalReaderStop(t *testing.T) {
+  goroutines := runtime.NumGoroutine()
+
+  reader := NewIncrementalReader("")
+  reader.Feed("(a")
+  if _, err := reader.Next(); err != ErrIncomplete { t.Fatal("Expected ErrIncomplete, got: ", err) }
+
+  reader.Stop()
+  if _, err := reader.Next(); err != io.EOF { t.Error("Expected EOF, got: ", err) }
+
+  for i := 0; runtime.NumGoroutine() > goroutines; i++ {
+    if i == 100 { t.Fatal("Expected the reader goroutine to end") }
+    time.Sleep(time.Millisecond)
+  }
+}
+
+func TestIncrementalEvaluation(t *testing.T) {
+  reader := NewIncrementalReader("")
+  context := EmptyEvaluationContext()
+
+  var last *Value
+  for _, chunk := range []string{"(def x", " 2)\n(+ x", " 3)\n"} {
+    reader.Feed(chunk)
+
+    for form, err := reader.Next(); err == nil; form, err = reader.Next() {
+      last = form.Evaluate(context)
+    }
+  }
+
+  AssertNumber(t, 5, last)
+}
+
+func TestIncrementalReaderInvalidUTF8(t *testing.T) {
+  reader := NewIncrementalReader("")
+  reader.Feed("'\xff' (a)\n")
+
+  form, err := reader.Next()
+  if err != nil || form.AssertStringType() != "�" { t.Fatal("Expected '\\uFFFD', got: ", form, err) }
+
+  form, err = reader.Next()
+  if err != nil || form.String() != "[ a ]" || form.Pos.String() != "1:5" { t.Fatal("Expected (a) at 1:5, got: ", form, err) }
+
+  if _, err := reader.Next(); err != ErrIncomplete { t.Error("Expected ErrIncomplete, got: ", err) }
+}
+
+func TestIncrementalReaderSplitLiterals(t *testing.T) {
+  reader := NewIncrementalReader("")
+
+  for _, chunk := range []string{"(a 'x", " )y", "' b", " c"} {
+    reader.Feed(chunk)
+    if _, err := reader.Next(); err != ErrIncomplete { t.Fatal("Expected ErrIncomplete after ", chunk, ", got: ", err) }
+  }
+
+  reader.Feed(")\n")
+  form, err := reader.Next()
+
+  var expected string = "[ a, 'x )y', b, c ]"
+  if err != nil { t.Fatal(err) }
+  if form.String() != expected { t.Error("Expected ", expected, ", got: ", form) }
+}
+
+func BenchmarkIncrementalReader(b *testing.B) {
+  chunk := "(a 'text' (b 1))\n"
+
+  for i := 0; i < b.N; i++ {
+    reader := NewIncrementalReader("")
+
+    reader.Feed("(list\n")
+    for j := 0; j < 1 << 10; j++ {
+      reader.Feed(chunk)
+      if _, err := reader.Next(); err != ErrIncomplete { b.Fatal(err) }
+    }
+
+    reader.Feed(")\n")
+    if _, err := reader.Next(); err != nil { b.Fatal(err) }
+
+    reader.Close()
+    if _, err := reader.Next(); err != io.EOF { b.Fatal(err) }
+  }
+}
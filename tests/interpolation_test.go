@@ -0,0 +1,58 @@
+package yasp
+
+import (
+  "testing"
+
+  . "../util";
+)
+
+func TestInterpolation(t *testing.T) {
+  var expected string = "hello world, you have 3 items"
+  actual := ParseAndEvaluate(`
+    (let (name 'world' items (list 1 2 3))
+      "hello ${name}, you have ${(len items)} items")`)
+
+  AssertString(t, expected, actual)
+}
+
+func TestInterpolationOnly(t *testing.T) {
+  var expected string = "42"
+  actual := ParseAndEvaluate(`"${42}"`)
+
+  AssertString(t, expected, actual)
+}
+
+func TestNestedInterpolation(t *testing.T) {
+  var expected string = "a [1, 2] b"
+  actual := ParseAndEvaluate(`"a ${(str "[${1}, ${2}]")} b"`)
+
+  AssertString(t, expected, actual)
+}
+
+func TestEscapedInterpolation(t *testing.T) {
+  var expected string = "${name} $5"
+  actual := ParseAndEvaluate(`"\${name} $5"`)
+
+  AssertString(t, expected, actual)
+}
+
+func TestSingleQuotedNotInterpolated(t *testing.T) {
+  var expected string = "${name}"
+  actual := ParseAndEvaluate(`'${name}'`)
+
+  AssertString(t, expected, actual)
+}
+
+func TestInterpolationWithShadowedBuiltins(t *testing.T) {
+  var expected string = "a 1 b"
+  actual := ParseAndEvaluate(`(let (str 5 concat 6) "a ${1} b")`)
+
+  AssertString(t, expected, actual)
+}
+
+func TestInterpolationRepr(t *testing.T) {
+  var expected string = `"a \"${x}\" \$ ${(+ 1 2)}"`
+  actual := ParseAndEvaluate(`(repr (quote "a \"${x}\" \$ ${(+ 1 2)}"))`)
+
+  AssertString(t, expected, actual)
+}
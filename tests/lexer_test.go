@@ -0,0 +1,69 @@
+package yasp
+
+import (
+  "testing"
+
+  . "../src";
+  . "../util";
+)
+
+func lex(t *testing.T, source string) []*Value {
+  parsing, err := Parse(source, "")
+  if err != nil { t.Fatal(err) }
+
+  return parsing.Forms()
+}
+
+func TestLexIdentifiers(t *testing.T) {
+  ids := []string {
+    "ColoredCircleWithData", "RED", "YaspNode", "isDigit", "tryParse",
+    "a1", "x'", "empty?", "set!", "<=", "+", "-", "_", "&",
+    "a.b", "ns/name", ":key", "a:b", ".",
+    "привет", "λ", "日本語", "naïve", "Ωmega2",
+  }
+
+  for _, id := range ids {
+    forms := lex(t, id)
+
+    if len(forms) != 1 || forms[0].T != TypeID || forms[0].AssertIdType() != id {
+      t.Error("Expected single ID ", id, ", got: ", forms)
+    }
+  }
+}
+
+func TestLexMixed(t *testing.T) {
+  forms := lex(t, "(Point x1 42 'str' Ж)")
+
+  if len(forms) != 1 || forms[0].T != TypeExpression { t.Fatal("Expected single expression, got: ", forms) }
+
+  expected := []Type{TypeID, TypeID, TypeNumber, TypeString, TypeID}
+  actual := forms[0].AssertExpressionType().Expand()
+
+  if len(actual) != len(expected) { t.Fatal("Expected ", len(expected), " tokens, got: ", actual) }
+
+  for i, x := range actual {
+    if x.T != expected[i] { t.Error("Token ", i, ": expected type ", expected[i], ", got: ", x) }
+  }
+}
+
+func TestLexPositions(t *testing.T) {
+  forms := lex(t, "(a\n  Бб c)")
+  elements := forms[0].AssertExpressionType().Expand()
+
+  if elements[1].Pos.String() != "2:3" || elements[2].Pos.String() != "2:6" {
+    t.Error("Unexpected positions: ", elements[1].Pos, " ", elements[2].Pos)
+  }
+}
+
+func TestLexInvalidIdentifier(t *testing.T) {
+  if _, err := Parse("(a→b)", ""); err == nil {
+    t.Error("Expected error for non-letter character in identifier")
+  }
+}
+
+func TestCaseSensitiveIdentifiers(t *testing.T) {
+  var expected uint64 = 1
+  actual := ParseAndEvaluate("(def Abc 1)\n(def abc 2)\nAbc")
+
+  AssertNumber(t, expected, actual)
+}
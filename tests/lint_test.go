@@ -0,0 +1,78 @@
+package yasp
+
+import (
+  "io/ioutil"
+  "strings"
+  "testing"
+
+  . "../src";
+)
+
+func assertLint(t *testing.T, source string, expected ...string) {
+  actual := []string{}
+  for _, d := range Lint(source, "", LintConfig{}) {
+    actual = append(actual, d.String())
+  }
+
+  if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+    t.Errorf("Expected:\n%v\ngot:\n%v", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+  }
+}
+
+func TestLintClean(t *testing.T) {
+  assertLint(t, `(defstruct Point x y)
+(defenum Color red green)
+(defn area (p &optional (scale 1)) (match p ((Point x y) (* scale (* x y)))))
+(defn name (c) (switch c red 'red' green 'green' 'other'))
+(def _ignored (fn (& rest) rest))
+(print (area (Point 1 2)) (name (Color red)) (map (fn (x) (+ x 1)) (list 1 2)))
+(try (throw 'a') (catch e (error-message e)) (finally (print 'done')))
+(later 1)
+(defn later (x) x)`)
+}
+
+func TestLintArity(t *testing.T) {
+  assertLint(t, "(if 1 2)\n(defn f (a &optional b) (+ a b))\n(f)\n(f 1 2 3)\n(defn g (a & r) (prepend a r))\n(g 1 2 3 4)",
+    "1:1: error: if expects 3 arguments, got 2 [arity]",
+    "3:1: error: f expects 1 to 2 arguments, got 0 [arity]",
+    "4:1: error: f expects 1 to 2 arguments, got 3 [arity]")
+}
+
+func TestLintUnbound(t *testing.T) {
+  assertLint(t, "(let (a 1) (+ a b))\n(print :keyword (inc 1))",
+    "1:17: error: b is not defined [unbound]")
+}
+
+func TestLintBindings(t *testing.T) {
+  assertLint(t, "(let (a 1 b) a)",
+    "1:6: error: let bindings must be name value pairs, got 3 values [let-bindings]")
+  assertLint(t, "(let (a 1 _b 2) a)\n(fn (x y) x)",
+    "2:8: warning: y is never used [unused]")
+}
+
+func TestLintSwitchAndShadowing(t *testing.T) {
+  assertLint(t, "(def x 1)\n(switch x 1 'one')\n(fn (list) list)",
+    "2:1: warning: switch has no default branch [switch-default]",
+    "3:6: warning: list shadows a builtin [shadowed-builtin]")
+}
+
+func TestLintConfigAndSyntax(t *testing.T) {
+  diagnostics := Lint("(fn (x) 1)", "", LintConfig{Disabled: map[string]bool{RuleUnused: true}})
+  if len(diagnostics) != 0 { t.Errorf("Expected no diagnostics, got %v", diagnostics) }
+
+  assertLint(t, "(print 'a'", "1:1: error: unclosed '(', expected ')' [syntax]")
+
+  assertLint(t, "(def 1 2)\n(def (a b) 3)\n(defn 'f' (a) a)",
+    "1:6: error: def name must be an identifier, got 1 [syntax]",
+    "2:6: error: def name must be an identifier, got (a b) [syntax]",
+    "3:7: error: defn name must be an identifier, got 'f' [syntax]")
+}
+
+func TestLintPrelude(t *testing.T) {
+  source, err := ioutil.ReadFile("../src/prelude.yasp")
+  if err != nil { t.Fatal(err) }
+
+  for _, d := range Lint(string(source), "prelude.yasp", LintConfig{Disabled: map[string]bool{RuleShadowedBuiltin: true}}) {
+    if d.Severity == "error" { t.Error(d) }
+  }
+}
@@ -0,0 +1,45 @@
+package yasp
+
+import (
+  "testing"
+
+  //. "../src";
+  . "../util";
+)
+
+func TestDoubleQuotedString(t *testing.T) {
+  var expected string = "it's \"quoted\"\n"
+  actual := ParseAndEvaluate(`"it's \"quoted\"\n"`)
+
+  AssertString(t, expected, actual)
+}
+
+func TestRawString(t *testing.T) {
+  var expected string = "line \\n 'one'\n\"two\" three"
+  actual := ParseAndEvaluate(`"""
+line \n 'one'
+"two" three"""`)
+
+  AssertString(t, expected, actual)
+}
+
+func TestCharLiterals(t *testing.T) {
+  var expected string = "a \n\tA("
+  actual := ParseAndEvaluate(`(concat \a \space \newline \tab \A \()`)
+
+  AssertString(t, expected, actual)
+}
+
+func TestNumberLiterals(t *testing.T) {
+  var expected uint64 = 1000000 + 0xff + 0755 + 5
+  actual := ParseAndEvaluate("(+ 1_000_000 0xFF 0o755 0b101)")
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestLeadingZeroIsDecimal(t *testing.T) {
+  var expected uint64 = 10
+  actual := ParseAndEvaluate("010")
+
+  AssertNumber(t, expected, actual)
+}
@@ -0,0 +1,105 @@
+package yasp
+
+import (
+  "bufio"
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "io"
+  "strings"
+  "testing"
+
+  lsp "../lsp";
+)
+
+func lspRequest(id int, method string, params string) string {
+  body := fmt.Sprintf(`{"jsonrpc":"2.0","id":%v,"method":"%v","params":%v}`, id, method, params)
+  if id == 0 { body = fmt.Sprintf(`{"jsonrpc":"2.0","method":"%v","params":%v}`, method, params) }
+
+  return fmt.Sprintf("Content-Length: %v\r\n\r\n%v", len(body), body)
+}
+
+// runLsp sends requests, numbered from 1 unless they are notifications,
+// and returns responses by id and notifications by method.
+func runLsp(t *testing.T, requests ...string) map[string]string {
+  out := &bytes.Buffer{}
+
+  if err := lsp.NewServer(strings.NewReader(strings.Join(requests, "")), out).Run(); err != nil { t.Fatal(err) }
+
+  messages := make(map[string]string)
+  in := bufio.NewReader(out)
+
+  for {
+    if _, err := in.Peek(1); err == io.EOF { return messages }
+
+    var length int
+    if _, err := fmt.Fscanf(in, "Content-Length: %d\r\n\r\n", &length); err != nil { t.Fatal(err) }
+
+    body := make([]byte, length)
+    io.ReadFull(in, body)
+
+    m := struct {
+      ID *int
+      Method string
+      Result json.RawMessage
+      Params json.RawMessage
+    }{}
+    if err := json.Unmarshal(body, &m); err != nil { t.Fatal(err) }
+
+    if m.ID != nil {
+      messages[fmt.Sprint(*m.ID)] = string(m.Result)
+    } else {
+      messages[m.Method] = string(m.Params)
+    }
+  }
+}
+
+const lspSource = `(defn area (width height)   (* width height))
+(print (area 2 3) (map inc (list 1)) (undefined-name))
+`
+
+func TestLspSession(t *testing.T) {
+  open, _ := json.Marshal(lspSource)
+  at := func (line int, character int) string {
+    return fmt.Sprintf(`{"textDocument":{"uri":"file:///a.yasp"},"position":{"line":%v,"character":%v}}`, line, character)
+  }
+
+  messages := runLsp(t,
+    lspRequest(1, "initialize", `{}`),
+    lspRequest(0, "initialized", `{}`),
+    lspRequest(0, "textDocument/didOpen", `{"textDocument":{"uri":"file:///a.yasp","text":` + string(open) + `}}`),
+    lspRequest(2, "textDocument/definition", at(1, 9)),
+    lspRequest(3, "textDocument/hover", at(1, 9)),
+    lspRequest(4, "textDocument/hover", at(1, 23)),
+    lspRequest(5, "textDocument/completion", at(1, 21)),
+    lspRequest(6, "textDocument/formatting", `{"textDocument":{"uri":"file:///a.yasp"},"options":{}}`),
+    lspRequest(7, "shutdown", `null`),
+    lspRequest(0, "exit", `null`))
+
+  expected := map[string]string{
+    "2": `{"uri":"file:///a.yasp","range":{"start":{"line":0,"character":6},"end":{"line":0,"character":10}}}`,
+    "3": "{\"contents\":{\"kind\":\"markdown\",\"value\":\"```yasp\\n(defn area (width height))\\n```\"},\"range\":{\"start\":{\"line\":1,\"character\":8},\"end\":{\"line\":1,\"character\":12}}}",
+    "4": "{\"contents\":{\"kind\":\"markdown\",\"value\":\"```yasp\\n(defn inc (x))\\n```\"},\"range\":{\"start\":{\"line\":1,\"character\":23},\"end\":{\"line\":1,\"character\":26}}}",
+    "6": `[{"range":{"start":{"line":0,"character":0},"end":{"line":2,"character":0}},"newText":"(defn area (width height) (* width height))\n(print (area 2 3) (map inc (list 1)) (undefined-name))\n"}]`,
+    "7": `null`,
+    "textDocument/publishDiagnostics": `{"uri":"file:///a.yasp","diagnostics":[{"range":{"start":{"line":1,"character":38},"end":{"line":1,"character":52}},"severity":1,"code":"unbound","source":"yasp","message":"undefined-name is not defined"}]}`,
+  }
+
+  for id, result := range expected {
+    if messages[id] != result { t.Errorf("Expected %v to be:\n%v\ngot:\n%v", id, result, messages[id]) }
+  }
+
+  completion := []struct { Label string }{}
+  json.Unmarshal([]byte(messages["5"]), &completion)
+
+  labels := []string{}
+  for _, item := range completion { labels = append(labels, item.Label) }
+
+  if strings.Join(labels, " ") != "map match max" { t.Errorf("Expected completions of ma, got %v", labels) }
+}
+
+func TestLspExitWithoutShutdown(t *testing.T) {
+  err := lsp.NewServer(strings.NewReader(lspRequest(0, "exit", `null`)), &bytes.Buffer{}).Run()
+
+  if err == nil { t.Error("Expected an error") }
+}
@@ -0,0 +1,56 @@
+package yasp
+
+import (
+  "strings"
+  "testing"
+
+  //. "../src";
+  . "../util";
+)
+
+func TestMatchLiterals(t *testing.T) {
+  var expected string = "and"
+  actual := ParseAndEvaluate(`
+(match (list 'and' 1 2)
+  (('single' parser) 'single')
+  (('and' & parts) 'and')
+  (_ 'other'))`)
+
+  AssertString(t, expected, actual)
+}
+
+func TestMatchStructGuard(t *testing.T) {
+  var expected uint64 = 2
+  actual := ParseAndEvaluate(`
+(defstruct Point x y)
+(match (Point 3 1)
+  ((Point 0 _) 0)
+  ((Point x y) :when (< x y) 1)
+  ((Point x y) (- x y)))`)
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestMatchEnum(t *testing.T) {
+  var expected uint64 = 3
+  actual := ParseAndEvaluate(`
+(defenum Color RED GREEN BLUE)
+(match (list (Color BLUE) 3)
+  (((Color RED) n) 0)
+  (((Color BLUE) n) n))`)
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestMatchNonExhaustive(t *testing.T) {
+  defer func() {
+    r := recover()
+    message, _ := r.(string)
+
+    if !strings.Contains(message, "non-exhaustive match at 2:1") {
+      t.Error("Expected non-exhaustive match error with location, got: ", r)
+    }
+  }()
+
+  ParseAndEvaluate("(def x 5)\n(match x (0 'zero') ('five' 'string'))")
+}
@@ -0,0 +1,100 @@
+package yasp
+
+import (
+  "io/ioutil"
+  "path/filepath"
+  "strings"
+  "testing"
+
+  //. "../src";
+  . "../util";
+)
+
+func writeModules(t *testing.T, files map[string]string) string {
+  dir := t.TempDir()
+
+  for name, source := range files {
+    if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(source), 0644); err != nil {
+      t.Fatal(err)
+    }
+  }
+
+  return dir
+}
+
+func TestInlineModule(t *testing.T) {
+  var expected uint64 = 6
+  actual := ParseAndEvaluate(`
+(module math
+  (export double)
+  (defn helper (x) (+ x x))
+  (defn double (x) (helper x)))
+(math/double 3)`)
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestImport(t *testing.T) {
+  dir := writeModules(t, map[string]string{
+    "main.yasp": "(import 'lib' :as l)\n(export run)\n(defn run () (l/inc l/base))",
+    "lib.yasp": "(export inc base)\n(def base 41)\n(defn inc (x) (+ x 1))",
+  })
+
+  var expected uint64 = 42
+  actual := ParseAndEvaluate("(import '" + filepath.Join(dir, "main.yasp") + "')\n(main/run)")
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestImportCached(t *testing.T) {
+  dir := writeModules(t, map[string]string{
+    "state.yasp": "(export counter)\n(def counter (atom 0))",
+  })
+  path := filepath.Join(dir, "state")
+
+  var expected uint64 = 5
+  actual := ParseAndEvaluate("(import '" + path + "' :as a)\n(import '" + path + "' :as b)\n(reset! a/counter 5)\n(deref b/counter)")
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestImportNotExported(t *testing.T) {
+  dir := writeModules(t, map[string]string{
+    "lib.yasp": "(export a)\n(def a 1)\n(def b 2)",
+  })
+
+  defer func() {
+    message, _ := recover().(string)
+    if !strings.Contains(message, "b is not exported by module lib") { t.Error("Unexpected error: ", message) }
+  }()
+
+  ParseAndEvaluate("(import '" + filepath.Join(dir, "lib.yasp") + "')\nlib/b")
+}
+
+func TestImportNamedAfterFile(t *testing.T) {
+  dir := writeModules(t, map[string]string{
+    "lib.yasp": "(export a)\n(def a 1)\n(def b 2)",
+  })
+  path := filepath.Join(dir, "lib.yasp")
+
+  defer func() {
+    message, _ := recover().(string)
+    if !strings.Contains(message, "b is not exported by module lib") { t.Error("Unexpected error: ", message) }
+  }()
+
+  ParseAndEvaluate("(import '" + path + "' :as first)\n(import '" + path + "' :as second)\nsecond/b")
+}
+
+func TestImportCycle(t *testing.T) {
+  dir := writeModules(t, map[string]string{
+    "a.yasp": "(import 'b.yasp')",
+    "b.yasp": "(import 'a.yasp')",
+  })
+
+  defer func() {
+    message, _ := recover().(string)
+    if !strings.Contains(message, "import cycle") { t.Error("Unexpected error: ", message) }
+  }()
+
+  ParseAndEvaluate("(import '" + filepath.Join(dir, "a.yasp") + "')")
+}
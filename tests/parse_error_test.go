@@ -0,0 +1,77 @@
+package yasp
+
+import (
+  "testing"
+
+  . "../src";
+)
+
+func TestParseErrors(t *testing.T) {
+  _, err := Parse("(+ 1 2))\n(print 'a\n", "file.yasp")
+
+  errs, ok := err.(ParseErrors)
+  if !ok { t.Fatal("Expected ParseErrors, got: ", err) }
+
+  expected := []string{
+    "file.yasp:1:8: unexpected ')' without matching '('",
+    "file.yasp:2:1: unclosed '(', expected ')'",
+    "file.yasp:2:8: unterminated string, expected \"'\"",
+  }
+
+  if len(errs) != len(expected) { t.Fatal("Expected ", len(expected), " errors, got: ", errs) }
+
+  for i, e := range errs {
+    message := e.Error()[:len(expected[i])]
+    if message != expected[i] { t.Error("Expected ", expected[i], ", got: ", e) }
+  }
+}
+
+func TestParseErrorExcerpt(t *testing.T) {
+  _, err := Parse("(let (a 1)\n\t(+ a (b 2)", "")
+
+  errs, _ := err.(ParseErrors)
+  if len(errs) != 2 { t.Fatal("Expected 2 errors, got: ", err) }
+
+  var expected string = "1:1: unclosed '(', expected ')'\n  (let (a 1)\n  ^"
+  if errs[0].Error() != expected { t.Error("Expected ", expected, ", got: ", errs[0]) }
+
+  expected = "2:2: unclosed '(', expected ')'\n  \t(+ a (b 2)\n  \t^"
+  if errs[1].Error() != expected { t.Error("Expected ", expected, ", got: ", errs[1]) }
+}
+
+func TestParseErrorInvalidLiteral(t *testing.T) {
+  _, err := Parse("(+ 1\n  0x1_0000_0000_0000_0000)", "")
+
+  errs, _ := err.(ParseErrors)
+  if len(errs) != 1 || errs[0].Pos.Line != 2 || errs[0].Pos.Column != 3 {
+    t.Error("Expected invalid number at 2:3, got: ", err)
+  }
+}
+
+func TestParseValidSource(t *testing.T) {
+  _, err := Parse(`(defn f (a) "x ${(+ a 1)} \${y}") // f
+#_ (a b) /* c */ #"a\"b" \space """raw""" 0b1_0`, "")
+
+  if err != nil { t.Error("Expected no errors, got: ", err) }
+}
+
+func TestParseErrorMissingWhitespace(t *testing.T) {
+  _, err := Parse("(a(b)) 'x''y'", "")
+
+  errs, _ := err.(ParseErrors)
+  if len(errs) != 2 { t.Fatal("Expected 2 errors, got: ", err) }
+  if errs[0].Pos.Column != 3 || errs[1].Pos.Column != 11 { t.Error("Wrong positions: ", errs) }
+}
+
+func TestParseErrorRecovery(t *testing.T) {
+  _, err := Parse("(a #_) '\\q' \"${}\" ~)\n(b \"${c d}\" 0x1_0000_0000_0000_0000)", "")
+
+  errs, _ := err.(ParseErrors)
+
+  expected := []string{"1:4", "1:9", "1:14", "1:19", "1:20", "2:5", "2:13"}
+  if len(errs) != len(expected) { t.Fatal("Expected ", len(expected), " errors, got: ", err) }
+
+  for i, e := range errs {
+    if e.Pos.String() != expected[i] { t.Error("Expected error at ", expected[i], ", got: ", e) }
+  }
+}
@@ -0,0 +1,30 @@
+package yasp
+
+import (
+  "testing"
+
+  . "../src";
+  . "../util";
+)
+
+func TestPrelude(t *testing.T) {
+  var expected uint64 = 7
+  actual := ParseAndEvaluate("(traverse (fn (acc x next) (next (+ acc (parseDigit x)))) 0 (list '3' '4'))")
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestPreludeShadowing(t *testing.T) {
+  var expected uint64 = 2
+  actual := ParseAndEvaluate("(defn inc (x) (+ x 2))\n(inc 0)")
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestBareContext(t *testing.T) {
+  context := BareEvaluationContext()
+
+  if _, ok := context.Lookup("traverse"); ok {
+    t.Error("Bare context must not contain prelude definitions")
+  }
+}
@@ -0,0 +1,148 @@
+package yasp
+
+import (
+  "math/rand"
+  "reflect"
+  "strings"
+  "testing"
+  "testing/quick"
+
+  . "../src";
+  . "../util";
+)
+
+// code is a random tree of identifiers, numbers, strings and expressions.
+type code struct {
+  *Value
+}
+
+var idStarts = []rune("abcXYZ_-+*/!?<>=:.жλ")
+var idChars = append([]rune("09'\""), idStarts...)
+
+func randomID(random *rand.Rand) string {
+  id := []rune{idStarts[random.Intn(len(idStarts))]}
+
+  for i := random.Intn(4); i > 0; i-- {
+    id = append(id, idChars[random.Intn(len(idChars))])
+  }
+
+  // // and /* start comments
+  return strings.NewReplacer("//", "/a", "/*", "/a").Replace(string(id))
+}
+
+func randomString(random *rand.Rand) string {
+  chars := []rune("ab '\"\\\n\t\r\x01\x7f${}жλ")
+  s := []rune{}
+
+  for i := random.Intn(8); i > 0; i-- {
+    s = append(s, chars[random.Intn(len(chars))])
+  }
+
+  return string(s)
+}
+
+func randomCode(random *rand.Rand, depth int) *Value {
+  switch n := random.Intn(10); {
+  case n < 3: return &Value{T: TypeID, V: randomID(random)}
+  case n < 5: return &Value{T: TypeNumber, V: random.Uint64() >> uint(random.Intn(64))}
+  case n < 7 || depth > 3: return &Value{T: TypeString, V: randomString(random)}
+  default: {
+    s := CreateStack(CreateStack(nil))
+
+    for i := random.Intn(4); i > 0; i-- {
+      s.AddToStack(*randomCode(random, depth + 1))
+    }
+
+    return &Value{T: TypeExpression, V: s}
+  }
+  }
+}
+
+func (code) Generate(random *rand.Rand, size int) reflect.Value {
+  return reflect.ValueOf(code{randomCode(random, 0)})
+}
+
+// data is a random value built from numbers, strings, lists and quoted code.
+type data struct {
+  *Value
+}
+
+func randomData(random *rand.Rand, depth int) *Value {
+  switch n := random.Intn(10); {
+  case n < 2: return &Value{T: TypeNumber, V: random.Uint64()}
+  case n < 4: return &Value{T: TypeString, V: randomString(random)}
+  case n < 5: return &Value{T: TypeNil}
+  case n < 7 || depth > 3: return randomCode(random, depth)
+  default: {
+    lst := []*Value{}
+
+    for i := random.Intn(4); i > 0; i-- {
+      lst = append(lst, randomData(random, depth + 1))
+    }
+
+    return &Value{T: TypeList, V: lst}
+  }
+  }
+}
+
+func (data) Generate(random *rand.Rand, size int) reflect.Value {
+  return reflect.ValueOf(data{randomData(random, 0)})
+}
+
+func readOne(t *testing.T, source string) *Value {
+  parsing, err := Parse(source, "")
+  if err != nil { t.Fatal(source, ": ", err) }
+
+  forms := parsing.Forms()
+  if len(forms) != 1 { t.Fatal("Expected one form in ", source, ", got: ", forms) }
+
+  return forms[0]
+}
+
+func TestPrintReadsBackCode(t *testing.T) {
+  roundTrip := func (x code) bool {
+    read := readOne(t, x.Print())
+
+    if !read.Equals(x.Value) { t.Log(x.Print(), " read as ", read.Print()) }
+    return read.Equals(x.Value)
+  }
+
+  if err := quick.Check(roundTrip, &quick.Config{MaxCount: 1000}); err != nil { t.Error(err) }
+}
+
+func TestPrintEvaluatesBackData(t *testing.T) {
+  context := EmptyEvaluationContext()
+
+  roundTrip := func (x data) bool {
+    evaluated := Quote(x.Value)
+    evaluated = readOne(t, evaluated.Print()).Evaluate(context)
+
+    if !evaluated.Equals(x.Value) { t.Log(x.Print(), " evaluated to ", evaluated.Print()) }
+    return evaluated.Equals(x.Value)
+  }
+
+  if err := quick.Check(roundTrip, &quick.Config{MaxCount: 1000}); err != nil { t.Error(err) }
+}
+
+func TestPrint(t *testing.T) {
+  cases := []struct { source string; expected string } {
+    {`(list 1 "it's\n" (quote a) (quote (b c)) (list))`, `(list 1 'it\'s\n' (quote a) (quote (b c)) (list))`},
+    {`(fn (a &optional (b 1) & rest &key c) (+ a b))`, `(fn (a &optional (b 1) & rest &key c) (+ a b))`},
+    {`#"a\"b"`, `#"a\"b"`},
+    {`(atom (error 'e' 2))`, `(atom (error 'e' 2))`},
+    {`(defstruct Point x (y Number))`, `(defstruct Point x (y Number))`},
+    {`(let (e (defenum Color RED)) (Color RED))`, `(Color RED)`},
+  }
+
+  for _, c := range cases {
+    actual := ParseAndEvaluate(c.source).Print()
+    if actual != c.expected { t.Error("Expected ", c.expected, ", got: ", actual) }
+  }
+}
+
+func TestReprAndRead(t *testing.T) {
+  var expected string = "(quote (a 'b\\n'))"
+  actual := ParseAndEvaluate(`(repr (list (read "(a 'b\n')")))`)
+
+  if actual.AssertStringType() != "(list " + expected + ")" { t.Error("Expected (list ", expected, "), got: ", actual) }
+}
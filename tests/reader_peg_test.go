@@ -0,0 +1,96 @@
+//go:build peg
+// +build peg
+
+// Differential tests against the parser generated from src/syntax.peg,
+// run with make test-peg.
+
+package yasp
+
+import (
+  "fmt"
+  "math/rand"
+  "strings"
+  "testing"
+
+  . "../src";
+)
+
+func assertSameValue(t *testing.T, expected *Value, actual *Value) {
+  if expected.T != actual.T || expected.String() != actual.String() || expected.Pos != actual.Pos {
+    t.Errorf("Expected %v at %v, got: %v at %v", expected, expected.Pos, actual, actual.Pos)
+    return
+  }
+
+  if expected.T == TypeExpression {
+    assertSameValues(t, expected.AssertExpressionType().Expand(), actual.AssertExpressionType().Expand())
+  }
+}
+
+func assertSameValues(t *testing.T, expected []*Value, actual []*Value) {
+  if len(expected) != len(actual) {
+    t.Errorf("Expected %v, got: %v", expected, actual)
+    return
+  }
+
+  for i := range expected {
+    assertSameValue(t, expected[i], actual[i])
+  }
+}
+
+func assertSameParsing(t *testing.T, source string) {
+  expected, err := ParsePEG(source, "file.yasp")
+  if err != nil { t.Fatal(err) }
+
+  actual, err := Parse(source, "file.yasp")
+  if err != nil { t.Fatal(source, ": ", err) }
+
+  assertSameValues(t, expected.Forms(), actual.Forms())
+
+  if fmt.Sprint(expected.Comments) != fmt.Sprint(actual.Comments) {
+    t.Errorf("Expected comments %v, got: %v", expected.Comments, actual.Comments)
+  }
+}
+
+func TestReaderMatchesPEG(t *testing.T) {
+  for _, source := range readerSources {
+    assertSameParsing(t, source)
+  }
+}
+
+var readerTokens = []string{
+  "a", "-", "b2", "жж", ":k", "0", "12", "0x_f", "0b1", "'s'", "'\\n'", "\"d ${a}\"",
+  "\"\"\"r\"\"\"", "\\c", "\\tab", "#\"r\"", "()", "#_ x",
+}
+
+// randomSource joins random tokens and nested lists with random separators.
+func randomSource(random *rand.Rand, depth int) string {
+  var buffer strings.Builder
+
+  separators := []string{" ", "\n", "\t ", " // c\n", " /* c */ "}
+
+  for i := random.Intn(6); i >= 0; i-- {
+    if depth < 4 && random.Intn(4) == 0 {
+      buffer.WriteString("(" + randomSource(random, depth + 1) + ")")
+    } else {
+      buffer.WriteString(readerTokens[random.Intn(len(readerTokens))])
+    }
+
+    buffer.WriteString(separators[random.Intn(len(separators))])
+  }
+
+  return buffer.String()
+}
+
+func TestReaderMatchesPEGOnRandomSources(t *testing.T) {
+  random := rand.New(rand.NewSource(42))
+
+  for i := 0; i < 500; i++ {
+    assertSameParsing(t, randomSource(random, 0))
+  }
+}
+
+func BenchmarkPEG(b *testing.B) {
+  for i := 0; i < b.N; i++ {
+    if _, err := ParsePEG(readerBenchmarkSource, ""); err != nil { b.Fatal(err) }
+  }
+}
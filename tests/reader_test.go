@@ -0,0 +1,65 @@
+package yasp
+
+import (
+  "fmt"
+  "io"
+  "strings"
+  "testing"
+
+  . "../src";
+)
+
+var readerSources = []string{
+  "",
+  "  // only a comment\n",
+  "(+ 1 2)",
+  "(defn f (a &optional (b 1) & rest) (+ a b)) (f 1)",
+  "(a (b (c)) () ( d ))",
+  "'single \\' \\n \\t \\\\ \\101 \\0x41' \"double \\\" \\$ ${(+ 1 2)} ${x}\"",
+  "\"\"\"\nraw 'text'\n\"\"\" \\a \\space \\newline \\u0416 \\( \\ж",
+  "0x1F 0o17 0b1_0 1_000 007",
+  "#\"a\\\"b[0-9]+\" #_ (ignored #_ twice) kept",
+  "/* block /* nested */ */ (a //line\n b) // end",
+  "жук ÿ-2 a.b/c :key ?x <=> a'b\"",
+  "(a\r\n\tb)",
+  "'привет' Ωmega2 (ж \"${жук} ок\") // коммент",
+}
+
+func TestReaderSources(t *testing.T) {
+  for _, source := range readerSources {
+    if _, err := Parse(source, "file.yasp"); err != nil { t.Error(source, ": ", err) }
+  }
+}
+
+func TestReaderErrors(t *testing.T) {
+  for _, source := range []string{"(a", "a)", "'x", "(a(b))", "\"${}\"", "0x1_0000_0000_0000_0000", "'\\q'"} {
+    _, err := Parse(source, "")
+
+    errs, ok := err.(ParseErrors)
+    if !ok || len(errs) == 0 { t.Error("Expected errors for ", source, ", got: ", err) }
+  }
+}
+
+func TestReaderReadForm(t *testing.T) {
+  reader := NewReader(strings.NewReader("(a 1) b\n'c'"), "")
+
+  var forms []string
+  for {
+    form, err := reader.ReadForm()
+    if err == io.EOF { break }
+    if err != nil { t.Fatal(err) }
+
+    forms = append(forms, form.String())
+  }
+
+  var expected string = "[[ a, 1 ] b 'c']"
+  if fmt.Sprint(forms) != expected { t.Error("Expected ", expected, ", got: ", forms) }
+}
+
+var readerBenchmarkSource = strings.Repeat("(defn f (a b) (let (c 'text ж') (+ a b 0x10))) // comment\n", 1 << 12)
+
+func BenchmarkReader(b *testing.B) {
+  for i := 0; i < b.N; i++ {
+    if _, err := Parse(readerBenchmarkSource, ""); err != nil { b.Fatal(err) }
+  }
+}
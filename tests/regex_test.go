@@ -0,0 +1,40 @@
+package yasp
+
+import (
+  "testing"
+
+  //. "../src";
+  . "../util";
+)
+
+func TestRegexMatch(t *testing.T) {
+  var expected string = "12-ab"
+  actual := ParseAndEvaluate(`
+(let ((whole digits letters) (re-match #"(\d+)-([a-z]+)" '12-ab'))
+  (concat digits '-' letters))`)
+
+  AssertString(t, expected, actual)
+}
+
+func TestRegexFind(t *testing.T) {
+  var expected string = "x=1;y=2"
+  actual := ParseAndEvaluate(`
+(join ';' (map (fn ((whole name value)) (concat name '=' value))
+               (re-find-all (regex '(\\w+): (\\d+)') 'x: 1, y: 2')))`)
+
+  AssertString(t, expected, actual)
+}
+
+func TestRegexNoMatch(t *testing.T) {
+  var expected string = "nil"
+  actual := ParseAndEvaluate(`(typeof (re-match #"\d+" '12a'))`)
+
+  AssertString(t, expected, actual)
+}
+
+func TestRegexReplaceSplit(t *testing.T) {
+  var expected string = "a|b|c \"1\""
+  actual := ParseAndEvaluate(`(concat (join '|' (re-split #"\s*,\s*" 'a , b,c')) (re-replace #"(\d)" ' "$1"' '1'))`)
+
+  AssertString(t, expected, actual)
+}
@@ -0,0 +1,39 @@
+package yasp
+
+import (
+  "testing"
+
+  //. "../src";
+  . "../util";
+)
+
+func TestSet(t *testing.T) {
+  var expected uint64 = 3
+  actual := ParseAndEvaluate("(def n 1)\n(do (set! n (+ n 2)))\nn")
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestSetClosure(t *testing.T) {
+  var expected uint64 = 3
+  actual := ParseAndEvaluate(`
+(defn make-counter () (let (n 0) (fn () (set! n (+ n 1)))))
+(def counter (make-counter))
+(counter)
+(counter)
+(counter)`)
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestAtom(t *testing.T) {
+  var expected uint64 = 12
+  actual := ParseAndEvaluate(`
+(def cache (atom 1))
+(reset! cache 5)
+(swap! cache + 1)
+(swap! cache (fn (x y) (* x y)) 2)
+(deref cache)`)
+
+  AssertNumber(t, expected, actual)
+}
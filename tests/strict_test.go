@@ -0,0 +1,62 @@
+package yasp
+
+import (
+  "path/filepath"
+  "strings"
+  "testing"
+
+  . "../src";
+  . "../util";
+)
+
+func evaluateStrict(source string) (result *Value, err interface{}) {
+  defer func() { err = recover() }()
+
+  parsing, parseErr := Parse(source, "main.yasp")
+  if parseErr != nil { panic(parseErr) }
+
+  context := EmptyEvaluationContext()
+  context.Strict = true
+
+  return parsing.Evaluate(context), nil
+}
+
+func TestStrictUnbound(t *testing.T) {
+  _, err := evaluateStrict("(def name 'a')\n(concat nmae)")
+
+  if err != "unbound variable nmae at main.yasp:2:9" { t.Errorf("Expected unbound variable error, got %v", err) }
+}
+
+func TestStrictAllowsQuotedSymbolsAndKeywords(t *testing.T) {
+  result, err := evaluateStrict("(defn f (& xs &key (k 1)) k)\n(list (quote a) (f :k 2) (map + (list 1) (list 2)))")
+  if err != nil { t.Fatal(err) }
+
+  AssertString(t, "(list (quote a) 2 (list 3))", &Value{T: TypeString, V: result.Print()})
+}
+
+func TestStrictCatchable(t *testing.T) {
+  result, err := evaluateStrict("(try (+ 1 missing) (catch e (error-message e)))")
+  if err != nil { t.Fatal(err) }
+
+  AssertString(t, "unbound variable missing at main.yasp:1:11", result)
+}
+
+func TestLenientByDefault(t *testing.T) {
+  actual := ParseAndEvaluate("(typeof symbol)")
+
+  AssertString(t, "id", actual)
+}
+
+func TestStrictImportedFiles(t *testing.T) {
+  dir := writeModules(t, map[string]string{
+    "lib.yasp": "(export f)\n(defn f () (concat nmae))",
+  })
+
+  defer func() {
+    if r := recover(); r == nil || !strings.Contains(r.(string), "unbound variable nmae at " + filepath.Join(dir, "lib.yasp") + ":2:20") {
+      t.Errorf("Expected unbound variable error, got %v", r)
+    }
+  }()
+
+  ParseAndEvaluate("(import '" + filepath.Join(dir, "lib.yasp") + "')\n(lib/f)")
+}
@@ -0,0 +1,43 @@
+package yasp
+
+import (
+  "testing"
+
+  //. "../src";
+  . "../util";
+)
+
+func TestSplitJoin(t *testing.T) {
+  var expected string = "c+b+a"
+  actual := ParseAndEvaluate("(join '+' (reverse (split ',' 'a,b,c')))")
+
+  AssertString(t, expected, actual)
+}
+
+func TestStringPredicates(t *testing.T) {
+  var expected uint64 = 1 + 1 + 0 + 2
+  actual := ParseAndEvaluate("(+ (starts-with? 'ab' 'abc') (ends-with? 'bc' 'abc') (ends-with? 'b' 'abc') (index-of 'ж' 'ёлж'))")
+
+  AssertNumber(t, expected, actual)
+}
+
+func TestStringTransformations(t *testing.T) {
+  var expected string = "ПРИВЕТ-HELLO-ab"
+  actual := ParseAndEvaluate("(concat (upper (substring 0 6 'привет мир')) (replace ' ' '-' ' HELLO ') (lower (trim ' AB ')))")
+
+  AssertString(t, expected, actual)
+}
+
+func TestCharacters(t *testing.T) {
+  var expected string = "ЖЖЖ"
+  actual := ParseAndEvaluate("(repeat 3 (chr (ord 'Ж')))")
+
+  AssertString(t, expected, actual)
+}
+
+func TestStrFormat(t *testing.T) {
+  var expected string = "x=5 [1, a] 00ff: done"
+  actual := ParseAndEvaluate("(format '%v %04x: %s' (str 'x=' 5 ' ' (list 1 'a')) 255 'done')")
+
+  AssertString(t, expected, actual)
+}
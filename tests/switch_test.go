@@ -0,0 +1,28 @@
+package yasp
+
+import (
+  "testing"
+
+  //. "../src";
+  . "../util";
+)
+
+func TestSwitchWithoutDefault(t *testing.T) {
+  AssertString(t, "one", ParseAndEvaluate("(switch 1 1 'one' 2 'two')"))
+  AssertString(t, "two", ParseAndEvaluate("(switch 2 1 'one' 2 'two')"))
+}
+
+func TestSwitchWithDefault(t *testing.T) {
+  AssertString(t, "one", ParseAndEvaluate("(switch 1 1 'one' 'other')"))
+  AssertString(t, "two", ParseAndEvaluate("(switch 2 1 'one' 2 'two' 'other')"))
+  AssertString(t, "other", ParseAndEvaluate("(switch 3 1 'one' 2 'two' 'other')"))
+  AssertString(t, "other", ParseAndEvaluate("(switch 3 'other')"))
+}
+
+func TestSwitchNoMatch(t *testing.T) {
+  defer func() {
+    if r := recover(); r != "No default branch in switch" { t.Errorf("Expected no default branch error, got %v", r) }
+  }()
+
+  ParseAndEvaluate("(switch 3 1 'one' 2 'two')")
+}
@@ -0,0 +1,48 @@
+package yasp
+
+import (
+  "strings"
+  "testing"
+
+  . "../src";
+)
+
+var megabyte = strings.Repeat("(abc ж) ", 1 << 17)
+
+func benchmarkStringOperation(b *testing.B, expression string) {
+  parsing, err := Parse(expression, "")
+  if err != nil { b.Fatal(err) }
+
+  context := EmptyEvaluationContext()
+  context.Define("text", &Value{T: TypeString, V: megabyte})
+
+  b.ResetTimer()
+
+  for i := 0; i < b.N; i++ {
+    parsing.Evaluate(context)
+  }
+}
+
+func BenchmarkHead1MB(b *testing.B) { benchmarkStringOperation(b, "(head text)") }
+func BenchmarkTail1MB(b *testing.B) { benchmarkStringOperation(b, "(tail text)") }
+func BenchmarkTake1MB(b *testing.B) { benchmarkStringOperation(b, "(take 10 text)") }
+func BenchmarkSkip1MB(b *testing.B) { benchmarkStringOperation(b, "(skip 500000 text)") }
+func BenchmarkLen1MB(b *testing.B) { benchmarkStringOperation(b, "(len text)") }
+
+// walks the whole string like character-by-character parsers do
+func BenchmarkTailWalk1MB(b *testing.B) {
+  parsing, err := Parse("(tail text)", "")
+  if err != nil { b.Fatal(err) }
+
+  for i := 0; i < b.N; i++ {
+    context := EmptyEvaluationContext()
+    context.Define("text", &Value{T: TypeString, V: megabyte})
+
+    for {
+      rest := parsing.Evaluate(context)
+      if rest.AssertTextType().Len() == 0 { break }
+
+      context.Set("text", rest)
+    }
+  }
+}
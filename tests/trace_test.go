@@ -0,0 +1,51 @@
+package yasp
+
+import (
+  "testing"
+
+  . "../src";
+)
+
+func runSource(t *testing.T, source string) error {
+  parsing, err := Parse(source, "main.yasp")
+  if err != nil { t.Fatal(err) }
+
+  _, err = parsing.Run(EmptyEvaluationContext())
+
+  return err
+}
+
+func TestRuntimeErrorTrace(t *testing.T) {
+  err := runSource(t, `(defn inner (x) (+ x 'a'))
+(defn outer (x)
+  (inner (* x 2)))
+(outer 1)`)
+
+  expected := `error: is not a number for +
+  in (inner 2) at main.yasp:3:3
+  in (outer 1) at main.yasp:4:1`
+
+  if err == nil || err.Error() != expected { t.Errorf("Expected:\n%v\ngot:\n%v", expected, err) }
+
+  frames := err.(*RuntimeError).Trace
+  if len(frames) != 2 || frames[1].Args[0].AssertNumberType() != 1 { t.Errorf("Expected frames with arguments, got %v", frames) }
+}
+
+func TestRuntimeErrorKeepsThrowTrace(t *testing.T) {
+  err := runSource(t, `(defn fail (s) (throw (error 'failed')))
+(defn rethrow () (try (fail (quote x)) (catch e (throw e))))
+(map (fn (x) (rethrow)) (list 1))`)
+
+  expected := `error: failed
+  in (fail (quote x)) at main.yasp:2:23
+  in (rethrow) at main.yasp:3:14
+  in (<anonymous> 1)`
+
+  if err == nil || err.Error() != expected { t.Errorf("Expected:\n%v\ngot:\n%v", expected, err) }
+}
+
+func TestRunThrownValue(t *testing.T) {
+  err := runSource(t, "(def x 1)\n(throw (list x 'two'))\n(def x 2)")
+
+  if err == nil || err.Error() != "uncaught: (list 1 'two')" { t.Errorf("Expected uncaught value, got %v", err) }
+}
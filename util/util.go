@@ -7,12 +7,9 @@ import (
 )
 
 func ParseAndEvaluate(expr string) *Value {
-  yaspPeg := &YaspPEG{Buffer: expr}
-  yaspPeg.Init()
-  yaspPeg.Parsing.Init()
-  if err := yaspPeg.Parse(); err != nil {
+  parsing, err := Parse(expr, "")
+  if err != nil {
     log.Fatal(err)
   }
-  yaspPeg.Execute()
-	return yaspPeg.Evaluate(EmptyEvaluationContext())
+	return parsing.Evaluate(EmptyEvaluationContext())
 }